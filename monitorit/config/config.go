@@ -6,19 +6,62 @@ import (
 	"io/fs"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Notifier types that can be used to send alerts.
+const (
+	NotifierSMS     = "sms"
+	NotifierEmail   = "email"
+	NotifierWebhook = "webhook"
+	NotifierLog     = "log"
+)
+
 // Config stores all configuration required by monitorit.
 type Config struct {
-	DBPath              string
-	AlertJobCron        string
-	HTTPPort            string
-	TwilioAccountSID    string
-	TwilioAuthToken     string
-	TwilioPhoneNumber   string
-	AlertJobPhoneNumber string
+	DBPath       string
+	AlertJobCron string
+	HTTPPort     string
+	// AdminUsername and AdminPassword are the HTTP basic auth credentials
+	// required to modify fridges, contacts, sensor tokens, and alerts.
+	AdminUsername string
+	AdminPassword string
+	// Notifier is the type of notifier used to send alerts.
+	// It is one of the Notifier* constants.
+	Notifier string
+	// AlertJobRecipient is who alerts are sent to. Its meaning depends on
+	// the notifier, ex: a phone number for SMS or an email address for email.
+	AlertJobRecipient string
+	// BaseURL is the URL monitorit can be reached at, ex: https://fridges.example.com.
+	// It is used to link to the fridge in alerts so they can be acknowledged. If empty, no link is sent.
+	BaseURL string
+	// AlertReminderInterval is how often a reminder is sent for an alert that has not been
+	// acknowledged. Zero means no reminders are sent.
+	AlertReminderInterval time.Duration
+	// RetentionJobCron is the cron expression for the retention job.
+	// If empty, the retention job is disabled and temperatures are kept forever.
+	RetentionJobCron string
+	// RawRetention is how long raw temperatures are kept before being rolled up into hourly buckets.
+	RawRetention time.Duration
+	// HourlyRetention is how long hourly buckets are kept before being rolled up into daily buckets.
+	HourlyRetention time.Duration
+
+	// Only set if Notifier is NotifierSMS.
+	TwilioAccountSID  string
+	TwilioAuthToken   string
+	TwilioPhoneNumber string
+
+	// Only set if Notifier is NotifierEmail.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Only set if Notifier is NotifierWebhook.
+	WebhookURL string
 }
 
 // Read reads the configuration from the current environment.
@@ -35,13 +78,56 @@ func Read() (Config, error) {
 
 	var missing []string
 	cfg := Config{
-		DBPath:              requireEnv("DB_PATH", &missing),
-		AlertJobCron:        requireEnv("ALERT_JOB_CRON", &missing),
-		HTTPPort:            getEnv("HTTP_PORT", "8080"),
-		TwilioAccountSID:    requireEnv("TWILIO_ACCOUNT_SID", &missing),
-		TwilioAuthToken:     requireEnv("TWILIO_AUTH_TOKEN", &missing),
-		TwilioPhoneNumber:   requireEnv("TWILIO_PHONE_NUMBER", &missing),
-		AlertJobPhoneNumber: requireEnv("ALERT_JOB_PHONE_NUMBER", &missing),
+		DBPath:        requireEnv("DB_PATH", &missing),
+		AlertJobCron:  requireEnv("ALERT_JOB_CRON", &missing),
+		HTTPPort:      getEnv("HTTP_PORT", "8080"),
+		AdminUsername: getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword: requireEnv("ADMIN_PASSWORD", &missing),
+		Notifier:      getEnv("NOTIFIER", NotifierSMS),
+		BaseURL:       strings.TrimSuffix(getEnv("BASE_URL", ""), "/"),
+	}
+	reminderInterval, err := time.ParseDuration(getEnv("ALERT_REMINDER_INTERVAL", "1h"))
+	if err != nil {
+		return cfg, fmt.Errorf("failed to parse ALERT_REMINDER_INTERVAL: %w", err)
+	}
+	cfg.AlertReminderInterval = reminderInterval
+
+	cfg.RetentionJobCron = getEnv("RETENTION_JOB_CRON", "")
+	if cfg.RetentionJobCron != "" {
+		if cfg.RawRetention, err = time.ParseDuration(getEnv("RAW_RETENTION", "720h")); err != nil {
+			return cfg, fmt.Errorf("failed to parse RAW_RETENTION: %w", err)
+		}
+		if cfg.HourlyRetention, err = time.ParseDuration(getEnv("HOURLY_RETENTION", "8760h")); err != nil {
+			return cfg, fmt.Errorf("failed to parse HOURLY_RETENTION: %w", err)
+		}
+		if cfg.RawRetention < time.Hour {
+			return cfg, fmt.Errorf("RAW_RETENTION must be at least 1h")
+		}
+		if cfg.HourlyRetention < cfg.RawRetention {
+			return cfg, fmt.Errorf("HOURLY_RETENTION must not be less than RAW_RETENTION")
+		}
+	}
+
+	// Only require the config for the notifier that is being used
+	switch cfg.Notifier {
+	case NotifierSMS:
+		cfg.TwilioAccountSID = requireEnv("TWILIO_ACCOUNT_SID", &missing)
+		cfg.TwilioAuthToken = requireEnv("TWILIO_AUTH_TOKEN", &missing)
+		cfg.TwilioPhoneNumber = requireEnv("TWILIO_PHONE_NUMBER", &missing)
+		cfg.AlertJobRecipient = requireEnv("ALERT_JOB_PHONE_NUMBER", &missing)
+	case NotifierEmail:
+		cfg.SMTPHost = requireEnv("SMTP_HOST", &missing)
+		cfg.SMTPPort = getEnv("SMTP_PORT", "587")
+		cfg.SMTPUsername = getEnv("SMTP_USERNAME", "")
+		cfg.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+		cfg.SMTPFrom = requireEnv("SMTP_FROM", &missing)
+		cfg.AlertJobRecipient = requireEnv("ALERT_JOB_EMAIL", &missing)
+	case NotifierWebhook:
+		cfg.WebhookURL = requireEnv("WEBHOOK_URL", &missing)
+	case NotifierLog:
+		// Nothing required
+	default:
+		return cfg, fmt.Errorf("unknown NOTIFIER %q, must be one of: %s, %s, %s, %s", cfg.Notifier, NotifierSMS, NotifierEmail, NotifierWebhook, NotifierLog)
 	}
 	if len(missing) > 0 {
 		return cfg, fmt.Errorf("required env vars missing: %s", strings.Join(missing, ", "))
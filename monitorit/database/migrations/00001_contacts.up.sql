@@ -0,0 +1,17 @@
+-- address is where alerts are sent, its format depends on the notifier being used
+-- ex: a phone number for SMS or an email address for email.
+CREATE TABLE contacts(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    address TEXT NOT NULL
+) STRICT;
+
+-- escalation_level orders contacts for a fridge, lower levels are notified first.
+CREATE TABLE fridge_contacts(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    contact_id INTEGER NOT NULL REFERENCES contacts(id),
+    escalation_level INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (fridge_id, contact_id)
+) STRICT;
+
+CREATE INDEX idx_fridge_contacts_contact_id ON fridge_contacts(contact_id);
@@ -0,0 +1,17 @@
+-- An alert is an incident for a fridge that people are notified about.
+-- It goes from open -> acknowledged (optional) -> resolved.
+CREATE TABLE alerts(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    kind TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'open',
+    message TEXT NOT NULL,
+    notification_count INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    last_notified_at TEXT NOT NULL DEFAULT (datetime('now')),
+    acknowledged_at TEXT,
+    resolved_at TEXT
+) STRICT;
+
+-- Only allow a single unresolved alert of each kind per fridge.
+CREATE UNIQUE INDEX idx_alerts_fridge_id_kind_unresolved ON alerts(fridge_id, kind) WHERE state != 'resolved';
@@ -0,0 +1,6 @@
+-- Number of consecutive readings outside of the safe range required to trigger an alert.
+ALTER TABLE fridges ADD COLUMN alert_sample_count INTEGER NOT NULL DEFAULT 3;
+-- Maximum amount of time without a reading before an alert is triggered.
+ALTER TABLE fridges ADD COLUMN max_silence_seconds INTEGER NOT NULL DEFAULT 1800;
+-- Amount inside the safe range a temperature must be before an alert is resolved.
+ALTER TABLE fridges ADD COLUMN temp_hysteresis REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Safe humidity range of the fridge, if null humidity isn't monitored.
+ALTER TABLE fridges ADD COLUMN min_humidity REAL;
+ALTER TABLE fridges ADD COLUMN max_humidity REAL;
@@ -0,0 +1,32 @@
+-- Aggregated temperatures that raw temperatures are rolled up into once they are old enough.
+-- Sums and sums of squares are stored instead of means and standard deviations
+-- so that rows can be combined into larger buckets.
+CREATE TABLE temperatures_hourly(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    bucket_start TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    value_min REAL NOT NULL,
+    value_max REAL NOT NULL,
+    value_sum REAL NOT NULL,
+    value_sum_sq REAL NOT NULL,
+    humidity_min REAL NOT NULL,
+    humidity_max REAL NOT NULL,
+    humidity_sum REAL NOT NULL,
+    humidity_sum_sq REAL NOT NULL,
+    PRIMARY KEY (fridge_id, bucket_start)
+) STRICT;
+
+CREATE TABLE temperatures_daily(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    bucket_start TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    value_min REAL NOT NULL,
+    value_max REAL NOT NULL,
+    value_sum REAL NOT NULL,
+    value_sum_sq REAL NOT NULL,
+    humidity_min REAL NOT NULL,
+    humidity_max REAL NOT NULL,
+    humidity_sum REAL NOT NULL,
+    humidity_sum_sq REAL NOT NULL,
+    PRIMARY KEY (fridge_id, bucket_start)
+) STRICT;
@@ -0,0 +1,11 @@
+-- API tokens used by sensors to send temperatures for a single fridge.
+-- Only a SHA-256 hash of the token is stored.
+CREATE TABLE sensor_tokens(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+) STRICT;
+
+CREATE INDEX idx_sensor_tokens_fridge_id ON sensor_tokens(fridge_id);
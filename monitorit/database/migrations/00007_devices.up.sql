@@ -0,0 +1,18 @@
+-- Physical sensors that send temperatures. A device is identified by its serial
+-- (or MAC address) and is assigned to the fridge it last sent a temperature for.
+CREATE TABLE devices(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    serial TEXT NOT NULL UNIQUE,
+    firmware TEXT NOT NULL DEFAULT '',
+    fridge_id INTEGER REFERENCES fridges(id),
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    last_seen_at TEXT NOT NULL DEFAULT (datetime('now'))
+) STRICT;
+
+CREATE INDEX idx_devices_fridge_id ON devices(fridge_id);
+
+-- The device that sent the temperature. NULL for temperatures sent before devices
+-- were tracked or by sensors that don't identify themselves.
+ALTER TABLE temperatures ADD COLUMN device_id INTEGER REFERENCES devices(id);
+
+CREATE INDEX idx_temperatures_device_id_created_at ON temperatures(device_id, created_at);
@@ -0,0 +1,16 @@
+-- The sensor channel (ex: probe) of the fridge that the temperature is for.
+-- Fridges with a single sensor use the default empty channel.
+ALTER TABLE temperatures ADD COLUMN channel TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_temperatures_fridge_id_channel_created_at ON temperatures(fridge_id, channel, created_at);
+
+-- The channel the alert is for. Empty for the default channel or if the alert
+-- is for the aggregate of all channels.
+ALTER TABLE alerts ADD COLUMN channel TEXT NOT NULL DEFAULT '';
+
+-- Now allow a single unresolved alert of each kind per channel of a fridge.
+DROP INDEX idx_alerts_fridge_id_kind_unresolved;
+CREATE UNIQUE INDEX idx_alerts_fridge_id_kind_channel_unresolved ON alerts(fridge_id, kind, channel) WHERE state != 'resolved';
+
+-- How the temperatures of each channel are combined when checking if a fridge is within its safe range.
+ALTER TABLE fridges ADD COLUMN channel_aggregate TEXT NOT NULL DEFAULT 'any';
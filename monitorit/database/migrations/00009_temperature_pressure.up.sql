@@ -0,0 +1,2 @@
+-- Air pressure in hPa. NULL if the sensor can't measure pressure.
+ALTER TABLE temperatures ADD COLUMN pressure REAL;
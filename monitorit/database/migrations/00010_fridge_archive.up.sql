@@ -0,0 +1,3 @@
+-- Fridges that are no longer used are archived instead of deleted so their history is kept.
+-- Archived fridges are hidden from the list of fridges and aren't checked for alerts.
+ALTER TABLE fridges ADD COLUMN archived_at TEXT;
@@ -0,0 +1,73 @@
+-- Humidity is NULL if the sensor can't measure it, ex: a DS18B20 or BMP280.
+-- SQLite can't drop NOT NULL from a column so the tables are recreated.
+CREATE TABLE temperatures_new(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    value REAL NOT NULL,
+    humidity REAL,
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    device_id INTEGER REFERENCES devices(id),
+    channel TEXT NOT NULL DEFAULT '',
+    pressure REAL
+) STRICT;
+
+-- Sensors without humidity were stored with a humidity of 0 which can't be a real reading in a fridge
+INSERT INTO temperatures_new(id, value, humidity, fridge_id, created_at, device_id, channel, pressure)
+    SELECT id, value, NULLIF(humidity, 0), fridge_id, created_at, device_id, channel, pressure FROM temperatures;
+
+DROP TABLE temperatures;
+ALTER TABLE temperatures_new RENAME TO temperatures;
+
+CREATE INDEX idx_temperatures_fridge_id_created_at ON temperatures(fridge_id, created_at);
+CREATE INDEX idx_temperatures_device_id_created_at ON temperatures(device_id, created_at);
+CREATE INDEX idx_temperatures_fridge_id_channel_created_at ON temperatures(fridge_id, channel, created_at);
+
+-- The rollups count how many temperatures had a humidity so the mean only includes them.
+-- humidity_min and humidity_max are NULL if none of them did.
+CREATE TABLE temperatures_hourly_new(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    bucket_start TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    value_min REAL NOT NULL,
+    value_max REAL NOT NULL,
+    value_sum REAL NOT NULL,
+    value_sum_sq REAL NOT NULL,
+    humidity_count INTEGER NOT NULL,
+    humidity_min REAL,
+    humidity_max REAL,
+    humidity_sum REAL NOT NULL,
+    humidity_sum_sq REAL NOT NULL,
+    PRIMARY KEY (fridge_id, bucket_start)
+) STRICT;
+
+INSERT INTO temperatures_hourly_new
+    SELECT fridge_id, bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+        count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq
+    FROM temperatures_hourly;
+
+DROP TABLE temperatures_hourly;
+ALTER TABLE temperatures_hourly_new RENAME TO temperatures_hourly;
+
+CREATE TABLE temperatures_daily_new(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    bucket_start TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    value_min REAL NOT NULL,
+    value_max REAL NOT NULL,
+    value_sum REAL NOT NULL,
+    value_sum_sq REAL NOT NULL,
+    humidity_count INTEGER NOT NULL,
+    humidity_min REAL,
+    humidity_max REAL,
+    humidity_sum REAL NOT NULL,
+    humidity_sum_sq REAL NOT NULL,
+    PRIMARY KEY (fridge_id, bucket_start)
+) STRICT;
+
+INSERT INTO temperatures_daily_new
+    SELECT fridge_id, bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+        count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq
+    FROM temperatures_daily;
+
+DROP TABLE temperatures_daily;
+ALTER TABLE temperatures_daily_new RENAME TO temperatures_daily;
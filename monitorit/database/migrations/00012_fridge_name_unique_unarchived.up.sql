@@ -0,0 +1,25 @@
+-- Only fridges in use need a unique name so that an archived fridge's name can be reused.
+-- SQLite can't drop a UNIQUE constraint from a column so the table is recreated.
+CREATE TABLE fridges_new(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    min_temp REAL NOT NULL,
+    max_temp REAL NOT NULL,
+    alerts_enabled INTEGER NOT NULL DEFAULT 0,
+    alert_sample_count INTEGER NOT NULL DEFAULT 3,
+    max_silence_seconds INTEGER NOT NULL DEFAULT 1800,
+    temp_hysteresis REAL NOT NULL DEFAULT 0,
+    min_humidity REAL,
+    max_humidity REAL,
+    channel_aggregate TEXT NOT NULL DEFAULT 'any',
+    archived_at TEXT
+) STRICT;
+
+INSERT INTO fridges_new(id, name, description, min_temp, max_temp, alerts_enabled, alert_sample_count, max_silence_seconds, temp_hysteresis, min_humidity, max_humidity, channel_aggregate, archived_at)
+    SELECT id, name, description, min_temp, max_temp, alerts_enabled, alert_sample_count, max_silence_seconds, temp_hysteresis, min_humidity, max_humidity, channel_aggregate, archived_at FROM fridges;
+
+DROP TABLE fridges;
+ALTER TABLE fridges_new RENAME TO fridges;
+
+CREATE UNIQUE INDEX idx_fridges_name_unarchived ON fridges(name) WHERE archived_at IS NULL;
@@ -0,0 +1,70 @@
+-- Rollups are kept per channel so the stats of a single channel are still available after temperatures
+-- are rolled up, and pressure is rolled up the same way as humidity.
+-- Existing rollups were the aggregate of all channels and didn't include pressure, so they are
+-- kept in the default channel without any pressure.
+-- SQLite can't change the primary key of a table so the tables are recreated.
+CREATE TABLE temperatures_hourly_new(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    channel TEXT NOT NULL DEFAULT '',
+    bucket_start TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    value_min REAL NOT NULL,
+    value_max REAL NOT NULL,
+    value_sum REAL NOT NULL,
+    value_sum_sq REAL NOT NULL,
+    humidity_count INTEGER NOT NULL,
+    humidity_min REAL,
+    humidity_max REAL,
+    humidity_sum REAL NOT NULL,
+    humidity_sum_sq REAL NOT NULL,
+    pressure_count INTEGER NOT NULL,
+    pressure_min REAL,
+    pressure_max REAL,
+    pressure_sum REAL NOT NULL,
+    pressure_sum_sq REAL NOT NULL,
+    PRIMARY KEY (fridge_id, channel, bucket_start)
+) STRICT;
+
+INSERT INTO temperatures_hourly_new(fridge_id, bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+        humidity_count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq,
+        pressure_count, pressure_min, pressure_max, pressure_sum, pressure_sum_sq)
+    SELECT fridge_id, bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+        humidity_count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq,
+        0, NULL, NULL, 0, 0
+    FROM temperatures_hourly;
+
+DROP TABLE temperatures_hourly;
+ALTER TABLE temperatures_hourly_new RENAME TO temperatures_hourly;
+
+CREATE TABLE temperatures_daily_new(
+    fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+    channel TEXT NOT NULL DEFAULT '',
+    bucket_start TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    value_min REAL NOT NULL,
+    value_max REAL NOT NULL,
+    value_sum REAL NOT NULL,
+    value_sum_sq REAL NOT NULL,
+    humidity_count INTEGER NOT NULL,
+    humidity_min REAL,
+    humidity_max REAL,
+    humidity_sum REAL NOT NULL,
+    humidity_sum_sq REAL NOT NULL,
+    pressure_count INTEGER NOT NULL,
+    pressure_min REAL,
+    pressure_max REAL,
+    pressure_sum REAL NOT NULL,
+    pressure_sum_sq REAL NOT NULL,
+    PRIMARY KEY (fridge_id, channel, bucket_start)
+) STRICT;
+
+INSERT INTO temperatures_daily_new(fridge_id, bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+        humidity_count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq,
+        pressure_count, pressure_min, pressure_max, pressure_sum, pressure_sum_sq)
+    SELECT fridge_id, bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+        humidity_count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq,
+        0, NULL, NULL, 0, 0
+    FROM temperatures_daily;
+
+DROP TABLE temperatures_daily;
+ALTER TABLE temperatures_daily_new RENAME TO temperatures_daily;
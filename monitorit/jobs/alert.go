@@ -2,23 +2,65 @@ package jobs
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"math"
+	"strings"
 	"time"
 
-	"github.com/cszatmary/fridge-monitor/monitorit/lib/sms"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/notify"
 	"github.com/cszatmary/fridge-monitor/monitorit/models"
 )
 
 type AlertJob struct {
-	fm          *models.FridgeManager
-	tm          *models.TemperatureManager
-	smsClient   *sms.Client
-	phoneNumber string
+	db        *sql.DB
+	fm        *models.FridgeManager
+	tm        *models.TemperatureManager
+	cm        *models.ContactManager
+	am        *models.AlertManager
+	notifier  notify.Notifier
+	hub       *events.Hub
+	recipient string
+	// reminderInterval is how often to resend a notification for an open alert.
+	// If zero, no reminders are sent.
+	reminderInterval time.Duration
+	// baseURL is used to link to the fridge in alerts. If empty, no link is sent.
+	baseURL string
 }
 
-func NewAlertJob(fm *models.FridgeManager, tm *models.TemperatureManager, smsClient *sms.Client, phoneNumber string) *AlertJob {
-	return &AlertJob{fm, tm, smsClient, phoneNumber}
+type AlertJobDependencies struct {
+	DB                 *sql.DB
+	FridgeManager      *models.FridgeManager
+	TemperatureManager *models.TemperatureManager
+	ContactManager     *models.ContactManager
+	AlertManager       *models.AlertManager
+	Notifier           notify.Notifier
+	// Events is the hub that changes to alerts are published to.
+	Events *events.Hub
+	// Recipient is the default recipient that is used for fridges with no contacts
+	// and for errors that aren't specific to a fridge.
+	Recipient        string
+	ReminderInterval time.Duration
+	// BaseURL is the URL monitorit can be reached at. It is used to link to the page
+	// of the fridge in alerts so the alert can be acknowledged. If empty, no link is sent.
+	BaseURL string
+}
+
+func NewAlertJob(deps AlertJobDependencies) *AlertJob {
+	return &AlertJob{
+		db:               deps.DB,
+		fm:               deps.FridgeManager,
+		tm:               deps.TemperatureManager,
+		cm:               deps.ContactManager,
+		am:               deps.AlertManager,
+		notifier:         deps.Notifier,
+		hub:              deps.Events,
+		recipient:        deps.Recipient,
+		reminderInterval: deps.ReminderInterval,
+		baseURL:          deps.BaseURL,
+	}
 }
 
 func (aj *AlertJob) Run() {
@@ -29,7 +71,7 @@ func (aj *AlertJob) Run() {
 		// TODO(@cszatmary): Think about how to handle errors.
 		// We need some way to surface this since if this fails then we won't get alerts.
 		// Could potentially send a text on job failure but that might be too spammy.
-		aj.alert("Failed to retrieve fridges: %v", err)
+		aj.alert([]string{aj.recipient}, "Failed to retrieve fridges: %v", err)
 		return
 	}
 	for _, f := range fridges {
@@ -37,67 +79,330 @@ func (aj *AlertJob) Run() {
 			continue
 		}
 		if err := aj.checkFridge(ctx, f); err != nil {
-			aj.alert("Failed to check fridge %s: %v", f.Name, err)
+			recipients, _ := aj.recipientsForFridge(ctx, f, 1)
+			aj.alert(recipients, "Failed to check fridge %s: %v", f.Name, err)
 		}
 	}
 }
 
+type checkStatus uint8
+
+const (
+	// checkUnknown means there isn't enough information to tell if the check passed or failed.
+	checkUnknown checkStatus = iota
+	checkPassed
+	checkFailed
+	// checkRetired means the check no longer applies, ex: the channel expired. Unlike checkPassed
+	// the problem didn't go away, so the alert is resolved without telling anyone it is all clear.
+	checkRetired
+)
+
+// channelExpiry is how long a channel can go without a temperature before it is no longer
+// considered part of the fridge. Until then, the channel is alerted on for missing data.
+// After that, its missing data alert is kept open until someone acknowledges it.
+const channelExpiry = 7 * 24 * time.Hour
+
+// check is the result of checking a single condition of a fridge.
+type check struct {
+	kind models.AlertKind
+	// channel is the channel of the fridge that was checked. It is empty for the default
+	// channel or if the check was for the aggregate of all channels.
+	channel string
+	status  checkStatus
+	// message describes the current state of the fridge for this check.
+	message string
+}
+
+// alertKey identifies the unresolved alert for a check.
+type alertKey struct {
+	kind    models.AlertKind
+	channel string
+}
+
 func (aj *AlertJob) checkFridge(ctx context.Context, fridge models.Fridge) error {
-	// TODO(@cszatmary): We should probably make this configurable.
-	const numTemps = 3
-	// Get the last n temperatures which will be used to perform checks.
-	temps, err := aj.tm.FindMostRecentByFridgeID(ctx, fridge.ID, numTemps)
+	// Update the alerts for the fridge based on the checks in a transaction, then send
+	// notifications once it has been committed. That way a failed update won't result
+	// in notifications being sent for state changes that never happened.
+	txn, err := aj.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create database transaction: %w", err)
+	}
+	defer txn.Rollback()
+	txnCtx := models.ContextWithTxn(ctx, txn)
+
+	unresolved, err := aj.am.FindAllUnresolvedByFridgeID(txnCtx, fridge.ID)
 	if err != nil {
 		return err
 	}
+	alerts := make(map[alertKey]*models.Alert)
+	for i := range unresolved {
+		alerts[alertKey{unresolved[i].Kind, unresolved[i].Channel}] = &unresolved[i]
+	}
 
-	// First check to make sure that a temperature was received in the expected interval.
-	// TODO(@cszatmary): We should probably make the interval configurable.
-	// Just use 30min for now since this seems reasonable.
-	var lastReceived time.Time
-	if len(temps) > 0 {
-		lastReceived = temps[0].CreatedAt.Time
+	checks, err := aj.evaluateFridge(txnCtx, fridge, alerts)
+	if err != nil {
+		return err
 	}
-	if time.Now().Sub(lastReceived) >= 30*time.Minute {
-		// Have not received a temperature in the expected interval, alert!
-		timeStr := "never"
-		if !lastReceived.IsZero() {
-			timeStr = lastReceived.Format(models.TimeFormatPretty)
+	// Alerts for channels that have expired or that are no longer checked separately
+	// because the channel aggregate changed won't have a check. Retire them since
+	// otherwise they would stay open forever.
+	checked := make(map[alertKey]bool)
+	for _, c := range checks {
+		checked[alertKey{c.kind, c.channel}] = true
+	}
+	for _, a := range unresolved {
+		if checked[alertKey{a.Kind, a.Channel}] {
+			continue
 		}
-		aj.alert("Temperature not received from fridge %q since %s", fridge.Name, timeStr)
-		return nil
+		// Every channel is checked for missing data so this means the channel expired, ex: a probe died.
+		// Keep the alert open until someone acknowledges it so the problem isn't forgotten.
+		if a.Kind == models.AlertKindNoData && a.State != models.AlertStateAcknowledged {
+			continue
+		}
+		checks = append(checks, check{
+			kind:    a.Kind,
+			channel: a.Channel,
+			status:  checkRetired,
+			message: fmt.Sprintf("%s is no longer being checked for %s", capitalize(channelSubject(fridge, a.Channel)), a.Kind),
+		})
 	}
 
-	// Now check to make sure that the last n temperatures have been within the safe range.
-	// All n temps must be outside to range to trigger an alert to avoid false alarms.
+	var notifications []notification
+	for _, c := range checks {
+		n, err := aj.transitionAlert(txnCtx, fridge, alerts[alertKey{c.kind, c.channel}], c)
+		if err != nil {
+			return err
+		}
+		if n != nil {
+			notifications = append(notifications, *n)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		return fmt.Errorf("failed to commit database transaction: %w", err)
+	}
+
+	for _, n := range notifications {
+		aj.hub.Publish(events.Event{Type: events.TypeAlert, FridgeID: fridge.ID, Data: n.alert})
+		if n.message == "" {
+			continue
+		}
+		recipients, err := aj.recipientsForFridge(ctx, fridge, n.levels)
+		if err != nil {
+			log.Printf("AlertJob Error: failed to retrieve contacts for fridge %s: %v", fridge.Name, err)
+		}
+		aj.alert(recipients, "%s", n.message)
+	}
+	return nil
+}
+
+// evaluateFridge performs all checks on the fridge. alerts are the current unresolved alerts for the fridge.
+// Each channel is checked for missing data. The temperature and humidity are checked either for each channel
+// or for the aggregate of the channels depending on the channel aggregate of the fridge.
+func (aj *AlertJob) evaluateFridge(ctx context.Context, fridge models.Fridge, alerts map[alertKey]*models.Alert) ([]check, error) {
+	channels, err := aj.tm.FindChannelsByFridgeID(ctx, fridge.ID, time.Now().Add(-channelExpiry))
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		// No temperatures at all, check the default channel so that we alert that nothing has been received
+		channels = []string{""}
+	}
+
+	perChannel := fridge.ChannelAggregate == models.ChannelAggregateAny
+	var checks []check
+	// Temperatures of each channel that is still receiving temperatures
+	var receiving [][]models.Temperature
+	for _, channel := range channels {
+		// Get the last n temperatures which will be used to perform checks.
+		temps, err := aj.tm.FindMostRecentByFridgeChannel(ctx, fridge.ID, channel, fridge.AlertSampleCount)
+		if err != nil {
+			return nil, err
+		}
+		subject := channelSubject(fridge, channel)
+
+		// First check to make sure that a temperature was received in the expected interval.
+		var lastReceived time.Time
+		if len(temps) > 0 {
+			lastReceived = temps[0].CreatedAt.Time
+		}
+		if time.Now().Sub(lastReceived) >= fridge.MaxSilence {
+			// Have not received a temperature in the expected interval, alert!
+			timeStr := "never"
+			if !lastReceived.IsZero() {
+				timeStr = lastReceived.Format(models.TimeFormatPretty)
+			}
+			checks = append(checks, check{
+				kind:    models.AlertKindNoData,
+				channel: channel,
+				status:  checkFailed,
+				message: fmt.Sprintf("Temperature not received from %s since %s", subject, timeStr),
+			})
+			if perChannel {
+				// Can't say anything about the temperature or humidity if we aren't receiving any
+				checks = append(
+					checks,
+					check{kind: models.AlertKindTemperature, channel: channel, status: checkUnknown},
+					check{kind: models.AlertKindHumidity, channel: channel, status: checkUnknown},
+				)
+			}
+			continue
+		}
+
+		checks = append(checks, check{
+			kind:    models.AlertKindNoData,
+			channel: channel,
+			status:  checkPassed,
+			message: fmt.Sprintf("Temperature received from %s at %s", subject, lastReceived.Format(models.TimeFormatPretty)),
+		})
+		receiving = append(receiving, temps)
+		if perChannel {
+			checks = append(
+				checks,
+				checkTemperature(fridge, subject, temps, alerts[alertKey{models.AlertKindTemperature, channel}] != nil),
+				checkHumidity(fridge, subject, temps),
+			)
+		}
+	}
+	if perChannel {
+		return checks, nil
+	}
+
+	// Check the aggregate of the channels that are receiving temperatures.
+	// Checks for the aggregate use the empty channel.
+	if len(receiving) == 0 {
+		return append(
+			checks,
+			check{kind: models.AlertKindTemperature, status: checkUnknown},
+			check{kind: models.AlertKindHumidity, status: checkUnknown},
+		), nil
+	}
+	temps := aggregateTemperatures(fridge.ChannelAggregate, receiving)
+	subject := fmt.Sprintf("fridge %q (%s of %d channels)", fridge.Name, fridge.ChannelAggregate, len(receiving))
+	return append(
+		checks,
+		checkTemperature(fridge, subject, temps, alerts[alertKey{models.AlertKindTemperature, ""}] != nil),
+		checkHumidity(fridge, subject, temps),
+	), nil
+}
+
+// aggregateTemperatures combines the temperatures of each channel into a single series of temperatures.
+// The i-th temperature of each channel, ordered from newest to oldest, is combined into the i-th temperature
+// of the result. The result only has as many temperatures as the channel with the fewest temperatures.
+func aggregateTemperatures(agg models.ChannelAggregate, channels [][]models.Temperature) []models.Temperature {
+	n := len(channels[0])
+	for _, temps := range channels[1:] {
+		if len(temps) < n {
+			n = len(temps)
+		}
+	}
+
+	result := make([]models.Temperature, n)
+	for i := range result {
+		t := channels[0][i]
+		// Only the channels with a humidity are combined since some sensors can't measure it
+		var humidities []float64
+		if t.Humidity != nil {
+			humidities = append(humidities, *t.Humidity)
+		}
+		for _, temps := range channels[1:] {
+			ct := temps[i]
+			switch agg {
+			case models.ChannelAggregateMax:
+				t.Value = math.Max(t.Value, ct.Value)
+			case models.ChannelAggregateMean:
+				t.Value += ct.Value
+			}
+			if ct.Humidity != nil {
+				humidities = append(humidities, *ct.Humidity)
+			}
+			if ct.CreatedAt.After(t.CreatedAt.Time) {
+				t.CreatedAt = ct.CreatedAt
+			}
+		}
+		if agg == models.ChannelAggregateMean {
+			t.Value /= float64(len(channels))
+		}
+		t.Humidity = nil
+		if len(humidities) > 0 {
+			humidity := humidities[0]
+			for _, h := range humidities[1:] {
+				switch agg {
+				case models.ChannelAggregateMax:
+					humidity = math.Max(humidity, h)
+				case models.ChannelAggregateMean:
+					humidity += h
+				}
+			}
+			if agg == models.ChannelAggregateMean {
+				humidity /= float64(len(humidities))
+			}
+			t.Humidity = &humidity
+		}
+		t.Channel = ""
+		result[i] = t
+	}
+	return result
+}
+
+// channelSubject describes the channel of the fridge for use in messages.
+func channelSubject(fridge models.Fridge, channel string) string {
+	if channel == "" {
+		return fmt.Sprintf("fridge %q", fridge.Name)
+	}
+	return fmt.Sprintf("channel %q of fridge %q", channel, fridge.Name)
+}
+
+// capitalize returns s with the first letter in uppercase.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// checkTemperature checks that the last n temperatures have been within the safe range.
+// temps must not be empty. subject describes what the temperatures are for in messages. alerting is whether or not there is currently an unresolved temperature alert, in which case
+// the hysteresis of the fridge is applied before considering the temperature normal.
+func checkTemperature(fridge models.Fridge, subject string, temps []models.Temperature, alerting bool) check {
+	c := check{kind: models.AlertKindTemperature, channel: temps[0].Channel}
 
 	// If we don't have n temperatures recorded yet then hold off, we need more data before we can be sure.
-	if len(temps) < numTemps {
-		log.Printf("AlertJob: Only have %d temperatures, waiting for more before checking status", len(temps))
-		return nil
+	if len(temps) < fridge.AlertSampleCount {
+		log.Printf("AlertJob: Only have %d temperatures for %s, waiting for more before checking status", len(temps), subject)
+		return c
 	}
 
 	status := temps[0].Status(fridge.MinTemp, fridge.MaxTemp)
 	if status == models.StatusNormal {
+		// If we are alerting, the temperature needs to be far enough inside the safe range
+		// before it is considered recovered. Until then keep the alert as is.
+		h := fridge.TempHysteresis
+		if 2*h >= fridge.MaxTemp-fridge.MinTemp {
+			// The margin leaves no temperature that counts as recovered so the alert would never be resolved.
+			// This is rejected when fridges are saved but could have been set before that, so ignore it.
+			h = 0
+		}
+		if alerting && temps[0].Status(fridge.MinTemp+h, fridge.MaxTemp-h) != models.StatusNormal {
+			log.Printf("AlertJob: Temperature of %s is recovering, waiting for it to be %.2f°C inside the safe range", subject, h)
+			return c
+		}
+
 		// If latest is normal then all is good even if the previous ones aren't since it has either
 		// recovered from a bad state or it was a flake.
-		return nil
+		c.status = checkPassed
+		c.message = fmt.Sprintf("Temperature of %s is back to normal, current temperature is %.2f°C", subject, temps[0].Value)
+		return c
 	}
 
-	// We have a bad status, check the other two to see if they are bad as well.
-	ok := false
+	// All n temps must be outside to range to trigger an alert to avoid false alarms.
+	// We have a bad status, check the other ones to see if they are bad as well.
 	for _, t := range temps[1:] {
 		if t.Status(fridge.MinTemp, fridge.MaxTemp) == models.StatusNormal {
-			ok = true
-			break
+			// Not all n temperatures were bad so wait and see what happens.
+			return c
 		}
 	}
 
-	if ok {
-		// Not all n temperatures were bad so wait and see what happens.
-		return nil
-	}
-
 	// All n temperatures are bad, we are in the danger zone, alert!
 	var statusStr string
 	var thresholdTemp string
@@ -109,16 +414,162 @@ func (aj *AlertJob) checkFridge(ctx context.Context, fridge models.Fridge) error
 		statusStr = "too high"
 		thresholdTemp = fmt.Sprintf("maximum safe temperature is %.2f°C", fridge.MaxTemp)
 	}
-	aj.alert("Temperature of fridge %q is %s, current temperature is %.2f°C, %s", fridge.Name, statusStr, temps[0].Value, thresholdTemp)
-	return nil
+	c.status = checkFailed
+	c.message = fmt.Sprintf("Temperature of %s is %s, current temperature is %.2f°C, %s", subject, statusStr, temps[0].Value, thresholdTemp)
+	return c
 }
 
-// alert performs an alert by both logging the message and sending an SMS.
-func (aj *AlertJob) alert(format string, a ...any) {
+// checkHumidity checks that the last n humidities have been within the safe range.
+// temps must not be empty. subject describes what the humidities are for in messages.
+func checkHumidity(fridge models.Fridge, subject string, temps []models.Temperature) check {
+	c := check{kind: models.AlertKindHumidity, channel: temps[0].Channel}
+	if fridge.MinHumidity == nil && fridge.MaxHumidity == nil {
+		// Not monitoring humidity, if there was an alert it will be resolved
+		c.status = checkPassed
+		c.message = fmt.Sprintf("Humidity of %s is no longer being monitored", subject)
+		return c
+	}
+	if len(temps) < fridge.AlertSampleCount {
+		return c
+	}
+	if temps[0].Humidity == nil {
+		// The sensor can't measure humidity so there's nothing to check
+		return c
+	}
+
+	status := temps[0].HumidityStatus(fridge.MinHumidity, fridge.MaxHumidity)
+	if status == models.HumidityNormal {
+		c.status = checkPassed
+		c.message = fmt.Sprintf("Humidity of %s is back to normal, current humidity is %.2f%%", subject, *temps[0].Humidity)
+		return c
+	}
+	// Same as temperature, all n humidities must be outside the range to avoid false alarms
+	for _, t := range temps[1:] {
+		if t.HumidityStatus(fridge.MinHumidity, fridge.MaxHumidity) == models.HumidityNormal {
+			return c
+		}
+	}
+
+	var statusStr string
+	var threshold string
+	switch status {
+	case models.HumidityTooLow:
+		statusStr = "too low"
+		threshold = fmt.Sprintf("minimum safe humidity is %.2f%%", *fridge.MinHumidity)
+	case models.HumidityTooHigh:
+		statusStr = "too high"
+		threshold = fmt.Sprintf("maximum safe humidity is %.2f%%", *fridge.MaxHumidity)
+	}
+	c.status = checkFailed
+	c.message = fmt.Sprintf("Humidity of %s is %s, current humidity is %.2f%%, %s", subject, statusStr, *temps[0].Humidity, threshold)
+	return c
+}
+
+// notification is a message that needs to be sent about a fridge.
+type notification struct {
+	// alert is the alert after the transition.
+	alert models.Alert
+	// message is empty if no one needs to be told about the transition, only subscribers to events.
+	message string
+	// levels is the number of escalation levels of contacts that should receive the notification.
+	levels int
+}
+
+// transitionAlert moves the alert for the check to its next state based on the result of the check.
+// alert is the current unresolved alert for the check, if there is one.
+// If a notification needs to be sent about the transition it is returned.
+func (aj *AlertJob) transitionAlert(ctx context.Context, fridge models.Fridge, alert *models.Alert, c check) (*notification, error) {
+	switch {
+	case c.status == checkUnknown:
+		// Nothing to do until we know more
+		return nil, nil
+	case (c.status == checkPassed || c.status == checkRetired) && alert == nil:
+		// All good
+		return nil, nil
+	case c.status == checkRetired:
+		// Nothing is checking the alert anymore, resolve it quietly since the problem may still be there
+		a, err := aj.am.Resolve(ctx, alert.ID, c.message)
+		if err != nil {
+			return nil, err
+		}
+		return &notification{alert: a}, nil
+	case c.status == checkPassed:
+		// Problem has gone away, resolve the alert and let everyone who was notified know
+		a, err := aj.am.Resolve(ctx, alert.ID, c.message)
+		if err != nil {
+			return nil, err
+		}
+		msg := fmt.Sprintf("All clear (alert %d): %s", a.ID, c.message)
+		return &notification{a, msg, a.NotificationCount}, nil
+	case alert == nil:
+		// New problem, open an alert
+		a, err := aj.am.InsertOne(ctx, fridge.ID, c.kind, c.channel, c.message)
+		if err != nil {
+			return nil, err
+		}
+		return &notification{a, aj.alertMessage(a, c.message), a.NotificationCount}, nil
+	case alert.State == models.AlertStateAcknowledged:
+		// Someone is already handling it, don't bother anyone
+		return nil, nil
+	case aj.reminderInterval > 0 && time.Since(alert.LastNotifiedAt.Time) >= aj.reminderInterval:
+		// Still a problem and no one has acknowledged it, send a reminder.
+		// Each reminder escalates to the next level of contacts.
+		a, err := aj.am.MarkNotified(ctx, alert.ID, c.message)
+		if err != nil {
+			return nil, err
+		}
+		msg := "Reminder: " + aj.alertMessage(a, c.message)
+		return &notification{a, msg, a.NotificationCount}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// alertMessage adds the alert to msg so recipients know which alert it is.
+// If the base URL is known, a link to the fridge where the alert can be acknowledged is added as well.
+func (aj *AlertJob) alertMessage(a models.Alert, msg string) string {
+	if aj.baseURL == "" {
+		return fmt.Sprintf("%s (alert %d)", msg, a.ID)
+	}
+	return fmt.Sprintf("%s (alert %d, acknowledge at %s/fridges/%d)", msg, a.ID, aj.baseURL, a.FridgeID)
+}
+
+// recipientsForFridge returns who should be alerted about the fridge.
+// Contacts are grouped by escalation level and the contacts in the first levels groups are returned.
+// If the fridge has no contacts, the default recipient is used.
+func (aj *AlertJob) recipientsForFridge(ctx context.Context, fridge models.Fridge, levels int) ([]string, error) {
+	contacts, err := aj.cm.FindAllByFridgeID(ctx, fridge.ID)
+	if err != nil {
+		// Still alert someone, falling back to the default is better than nothing
+		return []string{aj.recipient}, err
+	}
+	if len(contacts) == 0 {
+		return []string{aj.recipient}, nil
+	}
+
+	// Contacts are sorted by escalation level so count the levels as we go
+	var recipients []string
+	seenLevels := 0
+	for i, c := range contacts {
+		if i == 0 || c.EscalationLevel != contacts[i-1].EscalationLevel {
+			seenLevels++
+		}
+		if seenLevels > levels {
+			break
+		}
+		recipients = append(recipients, c.Address)
+	}
+	return recipients, nil
+}
+
+// alert performs an alert by both logging the message and sending it to each recipient using the notifier.
+func (aj *AlertJob) alert(recipients []string, format string, a ...any) {
 	msg := fmt.Sprintf(format, a...)
 	log.Print("AlertJob: " + msg)
-	if err := aj.smsClient.SendMessage(aj.phoneNumber, "MonitorIt: "+msg); err != nil {
-		// Nothing we can realistically do here besides log it
-		log.Printf("AlertJob Error: %v", err)
+	for _, r := range recipients {
+		if err := aj.notifier.SendMessage(r, "MonitorIt: "+msg); err != nil {
+			// Nothing we can realistically do here besides log it
+			log.Printf("AlertJob Error: %v", err)
+		}
 	}
 }
@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+)
+
+// temps creates temperatures with the given values, newest first, one minute apart.
+func temps(channel string, values ...float64) []models.Temperature {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	result := make([]models.Temperature, len(values))
+	for i, v := range values {
+		result[i] = models.Temperature{
+			Value:     v,
+			Channel:   channel,
+			CreatedAt: models.Time{Time: now.Add(-time.Duration(i) * time.Minute)},
+		}
+	}
+	return result
+}
+
+// withHumidity sets the humidity of each temperature. A negative humidity means there isn't one.
+func withHumidity(ts []models.Temperature, humidities ...float64) []models.Temperature {
+	for i, h := range humidities {
+		if h >= 0 {
+			h := h
+			ts[i].Humidity = &h
+		}
+	}
+	return ts
+}
+
+func TestAggregateTemperatures(t *testing.T) {
+	tests := []struct {
+		name          string
+		agg           models.ChannelAggregate
+		channels      [][]models.Temperature
+		wantValues    []float64
+		wantHumidity  []float64
+		wantCreatedAt []time.Time
+	}{
+		{
+			name: "max",
+			agg:  models.ChannelAggregateMax,
+			channels: [][]models.Temperature{
+				temps("top", 4, 9),
+				temps("bottom", 6, 2),
+			},
+			wantValues: []float64{6, 9},
+		},
+		{
+			name: "mean",
+			agg:  models.ChannelAggregateMean,
+			channels: [][]models.Temperature{
+				temps("top", 4, 9),
+				temps("bottom", 6, 2),
+			},
+			wantValues: []float64{5, 5.5},
+		},
+		{
+			name: "truncated to the channel with the fewest temperatures",
+			agg:  models.ChannelAggregateMax,
+			channels: [][]models.Temperature{
+				temps("top", 1, 2, 3),
+				temps("bottom", 4),
+			},
+			wantValues: []float64{4},
+		},
+		{
+			name: "max humidity of the channels that have one",
+			agg:  models.ChannelAggregateMax,
+			channels: [][]models.Temperature{
+				withHumidity(temps("top", 4, 4), 40, -1),
+				withHumidity(temps("bottom", 4, 4), -1, -1),
+				withHumidity(temps("middle", 4, 4), 60, -1),
+			},
+			wantValues:   []float64{4, 4},
+			wantHumidity: []float64{60, -1},
+		},
+		{
+			name: "mean humidity of the channels that have one",
+			agg:  models.ChannelAggregateMean,
+			channels: [][]models.Temperature{
+				withHumidity(temps("top", 4), 40),
+				withHumidity(temps("bottom", 4), -1),
+				withHumidity(temps("middle", 4), 60),
+			},
+			wantValues:   []float64{4},
+			wantHumidity: []float64{50},
+		},
+		{
+			name: "latest time of the channels",
+			agg:  models.ChannelAggregateMax,
+			channels: [][]models.Temperature{
+				temps("top", 4),
+				{{Value: 4, Channel: "bottom", CreatedAt: models.Time{Time: time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)}}},
+			},
+			wantValues:    []float64{4},
+			wantCreatedAt: []time.Time{time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateTemperatures(tt.agg, tt.channels)
+			if len(got) != len(tt.wantValues) {
+				t.Fatalf("got %d temperatures, want %d", len(got), len(tt.wantValues))
+			}
+			for i, temp := range got {
+				if temp.Value != tt.wantValues[i] {
+					t.Errorf("temperature %d: got value %g, want %g", i, temp.Value, tt.wantValues[i])
+				}
+				if temp.Channel != "" {
+					t.Errorf("temperature %d: got channel %q, want the empty aggregate channel", i, temp.Channel)
+				}
+				wantHumidity := -1.0
+				if tt.wantHumidity != nil {
+					wantHumidity = tt.wantHumidity[i]
+				}
+				switch {
+				case wantHumidity < 0 && temp.Humidity != nil:
+					t.Errorf("temperature %d: got humidity %g, want none", i, *temp.Humidity)
+				case wantHumidity >= 0 && temp.Humidity == nil:
+					t.Errorf("temperature %d: got no humidity, want %g", i, wantHumidity)
+				case wantHumidity >= 0 && *temp.Humidity != wantHumidity:
+					t.Errorf("temperature %d: got humidity %g, want %g", i, *temp.Humidity, wantHumidity)
+				}
+				if tt.wantCreatedAt != nil && !temp.CreatedAt.Equal(tt.wantCreatedAt[i]) {
+					t.Errorf("temperature %d: got createdAt %s, want %s", i, temp.CreatedAt, tt.wantCreatedAt[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTemperature(t *testing.T) {
+	fridge := models.Fridge{Name: "test", MinTemp: 0, MaxTemp: 8, AlertSampleCount: 3}
+	withHysteresis := fridge
+	withHysteresis.TempHysteresis = 1
+	// Leaves no temperature that counts as recovered, only possible for fridges saved before it was rejected
+	withLegacyHysteresis := fridge
+	withLegacyHysteresis.TempHysteresis = 4
+
+	tests := []struct {
+		name     string
+		fridge   models.Fridge
+		temps    []models.Temperature
+		alerting bool
+		want     checkStatus
+		// wantMessage is a substring of the message of the check
+		wantMessage string
+	}{
+		{"not enough temperatures", fridge, temps("", 20, 20), false, checkUnknown, ""},
+		{"normal", fridge, temps("", 4, 4, 4), false, checkPassed, "back to normal"},
+		{"at the limits", fridge, temps("", 8, 0, 8), false, checkPassed, "back to normal"},
+		{"recovered even if earlier ones were bad", fridge, temps("", 4, 20, 20), false, checkPassed, "back to normal"},
+		{"too high", fridge, temps("", 10, 9, 12), false, checkFailed, "too high, current temperature is 10.00°C"},
+		{"too low", fridge, temps("", -2, -1, -3), false, checkFailed, "too low, current temperature is -2.00°C"},
+		{"too high and too low", fridge, temps("", 10, -1, 12), false, checkFailed, "too high"},
+		{"flake", fridge, temps("", 10, 4, 12), false, checkUnknown, ""},
+		{"only the last n temperatures are passed", fridge, temps("", 10, 10, 10, 4), false, checkFailed, "too high"},
+		{"recovering within hysteresis", withHysteresis, temps("", 7.5, 9, 9), true, checkUnknown, ""},
+		{"recovered past hysteresis", withHysteresis, temps("", 6.5, 9, 9), true, checkPassed, "back to normal"},
+		{"hysteresis only applies when alerting", withHysteresis, temps("", 7.5, 7.5, 7.5), false, checkPassed, "back to normal"},
+		{"legacy hysteresis is ignored", withLegacyHysteresis, temps("", 7.5, 9, 9), true, checkPassed, "back to normal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := tt.temps
+			if len(ts) > tt.fridge.AlertSampleCount {
+				ts = ts[:tt.fridge.AlertSampleCount]
+			}
+			got := checkTemperature(tt.fridge, `fridge "test"`, ts, tt.alerting)
+			if got.kind != models.AlertKindTemperature {
+				t.Errorf("got kind %q, want %q", got.kind, models.AlertKindTemperature)
+			}
+			if got.status != tt.want {
+				t.Errorf("got status %d, want %d (message: %q)", got.status, tt.want, got.message)
+			}
+			if !strings.Contains(got.message, tt.wantMessage) {
+				t.Errorf("got message %q, want it to contain %q", got.message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -1,24 +1,51 @@
 package jobs
 
 import (
+	"database/sql"
 	"time"
 
-	"github.com/cszatmary/fridge-monitor/monitorit/lib/sms"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/notify"
 	"github.com/cszatmary/fridge-monitor/monitorit/models"
 	"github.com/go-co-op/gocron"
 )
 
 type SetupDependencies struct {
-	AlertJobCron        string
-	FridgeManager       *models.FridgeManager
-	TemperatureManager  *models.TemperatureManager
-	SMSClient           *sms.Client
-	AlertJobPhoneNumber string
+	DB                    *sql.DB
+	AlertJobCron          string
+	FridgeManager         *models.FridgeManager
+	TemperatureManager    *models.TemperatureManager
+	ContactManager        *models.ContactManager
+	AlertManager          *models.AlertManager
+	Notifier              notify.Notifier
+	Events                *events.Hub
+	AlertJobRecipient     string
+	BaseURL               string
+	AlertReminderInterval time.Duration
+	// If RetentionJobCron is empty the retention job is disabled.
+	RetentionJobCron string
+	RawRetention     time.Duration
+	HourlyRetention  time.Duration
 }
 
 func Setup(deps SetupDependencies) *gocron.Scheduler {
 	s := gocron.NewScheduler(time.UTC)
-	aj := NewAlertJob(deps.FridgeManager, deps.TemperatureManager, deps.SMSClient, deps.AlertJobPhoneNumber)
+	aj := NewAlertJob(AlertJobDependencies{
+		DB:                 deps.DB,
+		FridgeManager:      deps.FridgeManager,
+		TemperatureManager: deps.TemperatureManager,
+		ContactManager:     deps.ContactManager,
+		AlertManager:       deps.AlertManager,
+		Notifier:           deps.Notifier,
+		Events:             deps.Events,
+		Recipient:          deps.AlertJobRecipient,
+		ReminderInterval:   deps.AlertReminderInterval,
+		BaseURL:            deps.BaseURL,
+	})
 	s.Cron(deps.AlertJobCron).Do(aj.Run)
+	if deps.RetentionJobCron != "" {
+		rj := NewRetentionJob(deps.DB, deps.TemperatureManager, deps.RawRetention, deps.HourlyRetention)
+		s.Cron(deps.RetentionJobCron).Do(rj.Run)
+	}
 	return s
 }
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+)
+
+// RetentionJob keeps the temperatures table from growing forever by rolling up old temperatures.
+// Raw temperatures older than rawRetention are aggregated into hourly buckets, and hourly buckets
+// older than hourlyRetention are aggregated into daily buckets which are kept forever.
+type RetentionJob struct {
+	db              *sql.DB
+	tm              *models.TemperatureManager
+	rawRetention    time.Duration
+	hourlyRetention time.Duration
+}
+
+func NewRetentionJob(db *sql.DB, tm *models.TemperatureManager, rawRetention, hourlyRetention time.Duration) *RetentionJob {
+	return &RetentionJob{db, tm, rawRetention, hourlyRetention}
+}
+
+func (rj *RetentionJob) Run() {
+	ctx := context.Background()
+	txn, err := rj.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("RetentionJob Error: failed to create database transaction: %v", err)
+		return
+	}
+	defer txn.Rollback()
+	ctx = models.ContextWithTxn(ctx, txn)
+
+	// Only roll up complete hours and days so that buckets aren't split
+	now := time.Now().UTC()
+	rawCutoff := now.Add(-rj.rawRetention).Truncate(time.Hour)
+	hourlyCutoff := now.Add(-rj.hourlyRetention).Truncate(24 * time.Hour)
+
+	rawCount, err := rj.tm.RollupHourly(ctx, rawCutoff)
+	if err != nil {
+		log.Printf("RetentionJob Error: %v", err)
+		return
+	}
+	hourlyCount, err := rj.tm.RollupDaily(ctx, hourlyCutoff)
+	if err != nil {
+		log.Printf("RetentionJob Error: %v", err)
+		return
+	}
+	if err := txn.Commit(); err != nil {
+		log.Printf("RetentionJob Error: failed to commit database transaction: %v", err)
+		return
+	}
+	log.Printf("RetentionJob: Rolled up %d temperatures into hourly buckets and %d hourly buckets into daily buckets", rawCount, hourlyCount)
+}
@@ -1,14 +1,18 @@
 package apierror
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type Error interface {
 	error
 	Code() Code
 	Message() string
+	// Metadata returns optional structured details about the error that are returned to clients.
+	Metadata() Metadata
 }
 
 type DetailedError interface {
@@ -16,6 +20,37 @@ type DetailedError interface {
 	Details() string
 }
 
+// FieldError is a problem with a single field of a request.
+type FieldError struct {
+	// Field is the path of the field in the request body, ex: temperatures[0].value.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Metadata is optional structured details about an error. All fields can be empty.
+type Metadata struct {
+	// Fields has the problems with each field if the request was invalid.
+	Fields []FieldError
+	// RetryAfter is how long the client should wait before retrying the request.
+	RetryAfter time.Duration
+	// DocURL is a link to documentation about the error.
+	DocURL string
+}
+
+// merge fills in any fields of m that are empty with the values from other.
+func (m Metadata) merge(other Metadata) Metadata {
+	if m.Fields == nil {
+		m.Fields = other.Fields
+	}
+	if m.RetryAfter == 0 {
+		m.RetryAfter = other.RetryAfter
+	}
+	if m.DocURL == "" {
+		m.DocURL = other.DocURL
+	}
+	return m
+}
+
 type Code uint8
 
 const (
@@ -23,6 +58,10 @@ const (
 	CodeDatabase
 	CodeRecordNotFound
 	CodeInvalidParameter
+	CodeUnauthorized
+	CodeForbidden
+	CodeValidation
+	CodeConflict
 )
 
 func (c Code) String() string {
@@ -33,6 +72,14 @@ func (c Code) String() string {
 		return "err_record_not_found"
 	case CodeInvalidParameter:
 		return "err_invalid_parameter"
+	case CodeUnauthorized:
+		return "err_unauthorized"
+	case CodeForbidden:
+		return "err_forbidden"
+	case CodeValidation:
+		return "err_validation"
+	case CodeConflict:
+		return "err_conflict"
 	default:
 		return "err_unknown"
 	}
@@ -52,14 +99,36 @@ type standardError struct {
 	msg  string
 	op   Op
 	err  error
+	meta Metadata
 }
 
 func New(code Code, msg string, op Op) error {
-	return &standardError{code, msg, op, nil}
+	return &standardError{code: code, msg: msg, op: op}
 }
 
 func Wrap(err error, code Code, msg string, op Op) error {
-	return &standardError{code, msg, op, err}
+	return &standardError{code: code, msg: msg, op: op, err: err}
+}
+
+// NewWithMetadata is like New but also attaches structured details to the error.
+func NewWithMetadata(code Code, msg string, meta Metadata, op Op) error {
+	return &standardError{code: code, msg: msg, op: op, meta: meta}
+}
+
+// WrapWithMetadata is like Wrap but also attaches structured details to the error.
+func WrapWithMetadata(err error, code Code, msg string, meta Metadata, op Op) error {
+	return &standardError{code: code, msg: msg, op: op, err: err, meta: meta}
+}
+
+// NewValidation creates an error for a request with invalid fields.
+func NewValidation(fields []FieldError, op Op) error {
+	msg := "The request is invalid"
+	if len(fields) == 1 {
+		msg = fmt.Sprintf("%s: %s", fields[0].Field, fields[0].Message)
+	} else if len(fields) > 1 {
+		msg = fmt.Sprintf("The request has %d invalid fields", len(fields))
+	}
+	return NewWithMetadata(CodeValidation, msg, Metadata{Fields: fields}, op)
 }
 
 func (se *standardError) Error() string {
@@ -78,6 +147,16 @@ func (se *standardError) Message() string {
 	return se.msg
 }
 
+// Metadata returns the metadata of the error. Any fields that weren't set are
+// taken from the wrapped error so details aren't lost when an error is wrapped.
+func (se *standardError) Metadata() Metadata {
+	var prevErr Error
+	if errors.As(se.err, &prevErr) {
+		return se.meta.merge(prevErr.Metadata())
+	}
+	return se.meta
+}
+
 func (se *standardError) Details() string {
 	var sb strings.Builder
 	sb.WriteString(se.op.String())
@@ -94,6 +173,12 @@ func (se *standardError) Details() string {
 			sb.WriteString(se.err.Error())
 		}
 	}
+	for _, f := range se.meta.Fields {
+		sb.WriteString("\n\t")
+		sb.WriteString(f.Field)
+		sb.WriteString(": ")
+		sb.WriteString(f.Message)
+	}
 	return sb.String()
 }
 
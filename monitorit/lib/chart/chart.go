@@ -0,0 +1,205 @@
+// Package chart renders simple time series line charts as SVG so they can be
+// embedded directly in server rendered pages.
+package chart
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+)
+
+// Point is a single point in a time series.
+type Point struct {
+	Time  time.Time
+	Value float64
+	// Class, if set, highlights the point by drawing a marker with the given CSS class.
+	// The marker is filled using the CSS color of the class.
+	Class string
+}
+
+// Band is a range of values that is shaded on the chart, ex: a safe range.
+// Min or Max can be infinite if the band is unbounded in that direction.
+type Band struct {
+	Min float64
+	Max float64
+}
+
+// Chart is a line chart of a single time series.
+type Chart struct {
+	Width  int
+	Height int
+	// Start and End are the time range shown on the x axis.
+	Start time.Time
+	End   time.Time
+	// Unit is appended to the labels on the y axis.
+	Unit string
+	// TimeFormat is the format used for the labels on the x axis.
+	TimeFormat string
+	// MaxGap is the maximum amount of time between two points before the line is broken.
+	// If zero, the line is never broken.
+	MaxGap time.Duration
+	Points []Point
+	Band   *Band
+}
+
+const (
+	marginLeft   = 50
+	marginRight  = 10
+	marginTop    = 10
+	marginBottom = 25
+	numTicks     = 5
+)
+
+// SVG renders the chart as an SVG element.
+func (c Chart) SVG() string {
+	plotWidth := float64(c.Width - marginLeft - marginRight)
+	plotHeight := float64(c.Height - marginTop - marginBottom)
+	minY, maxY := c.yRange()
+	duration := c.End.Sub(c.Start)
+
+	x := func(t time.Time) float64 {
+		if duration <= 0 {
+			return marginLeft
+		}
+		return marginLeft + plotWidth*float64(t.Sub(c.Start))/float64(duration)
+	}
+	y := func(v float64) float64 {
+		return marginTop + plotHeight*(1-(v-minY)/(maxY-minY))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(
+		&sb,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="100%%" role="img" font-size="11" font-family="sans-serif">`,
+		c.Width,
+		c.Height,
+	)
+
+	if c.Band != nil {
+		// Clamp the band to the plot since it may be unbounded
+		top := y(math.Min(c.Band.Max, maxY))
+		bottom := y(math.Max(c.Band.Min, minY))
+		fmt.Fprintf(
+			&sb,
+			`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="rgb(40, 212, 40)" fill-opacity="0.12"/>`,
+			float64(marginLeft),
+			top,
+			plotWidth,
+			bottom-top,
+		)
+	}
+
+	// Axes with grid lines and labels
+	for i := 0; i <= numTicks; i++ {
+		v := minY + (maxY-minY)*float64(i)/numTicks
+		fmt.Fprintf(
+			&sb,
+			`<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/><text x="%d" y="%.1f" text-anchor="end" fill="#444">%.1f%s</text>`,
+			marginLeft,
+			y(v),
+			c.Width-marginRight,
+			y(v),
+			marginLeft-4,
+			y(v)+4,
+			v,
+			html.EscapeString(c.Unit),
+		)
+	}
+	for i := 0; i <= numTicks; i++ {
+		t := c.Start.Add(duration * time.Duration(i) / numTicks)
+		anchor := "middle"
+		switch i {
+		case 0:
+			anchor = "start"
+		case numTicks:
+			anchor = "end"
+		}
+		fmt.Fprintf(
+			&sb,
+			`<text x="%.1f" y="%d" text-anchor="%s" fill="#444">%s</text>`,
+			x(t),
+			c.Height-8,
+			anchor,
+			html.EscapeString(t.Format(c.TimeFormat)),
+		)
+	}
+	fmt.Fprintf(
+		&sb,
+		`<rect x="%d" y="%d" width="%.1f" height="%.1f" fill="none" stroke="#aaa"/>`,
+		marginLeft,
+		marginTop,
+		plotWidth,
+		plotHeight,
+	)
+
+	if len(c.Points) == 0 {
+		fmt.Fprintf(
+			&sb,
+			`<text x="%.1f" y="%.1f" text-anchor="middle" fill="#444">No data</text>`,
+			marginLeft+plotWidth/2,
+			marginTop+plotHeight/2,
+		)
+		sb.WriteString(`</svg>`)
+		return sb.String()
+	}
+
+	// Draw the line, starting a new segment whenever there is a gap in the data
+	sb.WriteString(`<path fill="none" stroke="#009879" stroke-width="1.5" d="`)
+	for i, p := range c.Points {
+		cmd := "L"
+		if i == 0 || (c.MaxGap > 0 && p.Time.Sub(c.Points[i-1].Time) > c.MaxGap) {
+			cmd = "M"
+		}
+		fmt.Fprintf(&sb, "%s%.1f %.1f ", cmd, x(p.Time), y(p.Value))
+	}
+	sb.WriteString(`"/>`)
+
+	for _, p := range c.Points {
+		if p.Class == "" {
+			continue
+		}
+		fmt.Fprintf(
+			&sb,
+			`<circle class="%s" cx="%.1f" cy="%.1f" r="3" fill="currentColor"><title>%.2f%s at %s</title></circle>`,
+			html.EscapeString(p.Class),
+			x(p.Time),
+			y(p.Value),
+			p.Value,
+			html.EscapeString(c.Unit),
+			html.EscapeString(p.Time.Format(c.TimeFormat)),
+		)
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+// yRange returns the range of values shown on the y axis.
+// It includes all points and the band with some padding.
+func (c Chart) yRange() (float64, float64) {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range c.Points {
+		minY = math.Min(minY, p.Value)
+		maxY = math.Max(maxY, p.Value)
+	}
+	if c.Band != nil {
+		if !math.IsInf(c.Band.Min, 0) {
+			minY = math.Min(minY, c.Band.Min)
+			maxY = math.Max(maxY, c.Band.Min)
+		}
+		if !math.IsInf(c.Band.Max, 0) {
+			minY = math.Min(minY, c.Band.Max)
+			maxY = math.Max(maxY, c.Band.Max)
+		}
+	}
+	if math.IsInf(minY, 0) {
+		// No data, just use an arbitrary range
+		return 0, 1
+	}
+	pad := (maxY - minY) * 0.1
+	if pad == 0 {
+		pad = 1
+	}
+	return minY - pad, maxY + pad
+}
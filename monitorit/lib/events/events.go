@@ -0,0 +1,73 @@
+// Package events delivers changes, ex: new temperatures, to everyone in the process that is interested in them
+// so they can be pushed to clients as they happen.
+package events
+
+import "sync"
+
+// Types of events.
+const (
+	// TypeTemperature is for a new temperature. The data is a models.Temperature.
+	TypeTemperature = "temperature"
+	// TypeAlert is for an alert that was opened or changed state. The data is a models.Alert.
+	TypeAlert = "alert"
+)
+
+// Event is a change to a fridge.
+type Event struct {
+	Type     string
+	FridgeID int64
+	Data     any
+}
+
+// subscriberBuffer is how many events can be waiting for a subscriber before new events are dropped.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	fridgeID int64
+	ch       chan Event
+}
+
+// Hub delivers published events to all subscribers. It is safe for concurrent use.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Subscribe returns a channel that receives the events for the fridge. If fridgeID is 0 the events for all
+// fridges are received. The returned func must be called once the events are no longer needed, it closes the channel.
+func (h *Hub) Subscribe(fridgeID int64) (<-chan Event, func()) {
+	s := &subscriber{fridgeID, make(chan Event, subscriberBuffer)}
+	h.mu.Lock()
+	h.subscribers[s] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, s)
+			close(s.ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Publish sends the event to all subscribers of its fridge. It never blocks, if a subscriber
+// isn't keeping up the event is dropped for it so one slow client can't hold up everyone else.
+func (h *Hub) Publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscribers {
+		if s.fridgeID != 0 && s.fridgeID != e.FridgeID {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
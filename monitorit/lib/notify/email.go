@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifier is a Notifier that sends messages as emails using SMTP.
+// The recipient is expected to be an email address.
+type EmailNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewEmailNotifier(host, port, username, password, from string) *EmailNotifier {
+	return &EmailNotifier{host, port, username, password, from}
+}
+
+func (en *EmailNotifier) SendMessage(recipient, message string) error {
+	// Parse the recipient so that it can't add headers to the email, ex: with a CRLF followed by Bcc
+	to, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return fmt.Errorf("invalid email address %q: %w", recipient, err)
+	}
+	// Use the first line of the message as the subject since alerts are short
+	subject := message
+	if i := strings.IndexByte(subject, '\n'); i >= 0 {
+		subject = subject[:i]
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", en.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	// The subject contains user provided values like the fridge name, encode it so that they can't add headers either
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	// Only authenticate if credentials were provided, some relays don't require it
+	var auth smtp.Auth
+	if en.username != "" {
+		auth = smtp.PlainAuth("", en.username, en.password, en.host)
+	}
+	addr := net.JoinHostPort(en.host, en.port)
+	if err := smtp.SendMail(addr, auth, en.from, []string{to.Address}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", recipient, err)
+	}
+	return nil
+}
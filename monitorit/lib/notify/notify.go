@@ -0,0 +1,37 @@
+// Package notify provides the different channels that alerts can be sent through.
+package notify
+
+import (
+	"log"
+)
+
+// Notifier is a channel that messages can be sent through.
+// The meaning of recipient depends on the implementation, ex: a phone number for SMS
+// or an email address for email. Implementations are free to ignore it if the
+// destination is fixed, ex: a webhook URL.
+type Notifier interface {
+	SendMessage(recipient, message string) error
+}
+
+// LogNotifier is a Notifier that only logs messages. It is useful for running
+// monitorit locally without credentials for an external service.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier creates a new LogNotifier. If logger is nil, the standard logger is used.
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger}
+}
+
+func (ln *LogNotifier) SendMessage(recipient, message string) error {
+	if recipient == "" {
+		ln.logger.Printf("Notification: %s", message)
+		return nil
+	}
+	ln.logger.Printf("Notification to %s: %s", recipient, message)
+	return nil
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier is a Notifier that sends messages as a JSON POST request to a URL.
+// The body is of the form {"recipient": "...", "text": "..."}. Using "text" as the key
+// means the body is accepted as is by the incoming webhooks of most chat tools.
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (wn *WebhookNotifier) SendMessage(recipient, message string) error {
+	body := struct {
+		Recipient string `json:"recipient,omitempty"`
+		Text      string `json:"text"`
+	}{Recipient: recipient, Text: message}
+	var bodyBuf bytes.Buffer
+	if err := json.NewEncoder(&bodyBuf).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode webhook body as JSON: %w", err)
+	}
+
+	resp, err := wn.httpClient.Post(wn.url, "application/json", &bodyBuf)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Include a bit of the response body since it usually says what went wrong
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook request unsuccessful: status %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 )
 
 // Client provides functionality for sending SMS messages using Twilio.
+// It implements notify.Notifier.
 type Client struct {
 	twilioClient      *twilio.RestClient
 	twilioPhoneNumber string
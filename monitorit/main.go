@@ -6,6 +6,8 @@ import (
 
 	"github.com/cszatmary/fridge-monitor/monitorit/config"
 	"github.com/cszatmary/fridge-monitor/monitorit/jobs"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/notify"
 	"github.com/cszatmary/fridge-monitor/monitorit/lib/sms"
 	"github.com/cszatmary/fridge-monitor/monitorit/models"
 	"github.com/cszatmary/fridge-monitor/monitorit/routes"
@@ -46,15 +48,30 @@ func main() {
 	// Initialize dependencies
 	fm := models.NewFridgeManager(db)
 	tm := models.NewTemperatureManager(db)
-	smsClient := sms.NewClient(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioPhoneNumber)
+	cm := models.NewContactManager(db)
+	am := models.NewAlertManager(db)
+	dm := models.NewDeviceManager(db)
+	stm := models.NewSensorTokenManager(db)
+	notifier := newNotifier(cfg)
+	hub := events.NewHub()
+	log.Printf("Using %s notifier for alerts", cfg.Notifier)
 
 	// Setup job runner
 	s := jobs.Setup(jobs.SetupDependencies{
-		AlertJobCron:        cfg.AlertJobCron,
-		FridgeManager:       fm,
-		TemperatureManager:  tm,
-		SMSClient:           smsClient,
-		AlertJobPhoneNumber: cfg.AlertJobPhoneNumber,
+		DB:                    db,
+		AlertJobCron:          cfg.AlertJobCron,
+		FridgeManager:         fm,
+		TemperatureManager:    tm,
+		ContactManager:        cm,
+		AlertManager:          am,
+		Notifier:              notifier,
+		Events:                hub,
+		AlertJobRecipient:     cfg.AlertJobRecipient,
+		AlertReminderInterval: cfg.AlertReminderInterval,
+		BaseURL:               cfg.BaseURL,
+		RetentionJobCron:      cfg.RetentionJobCron,
+		RawRetention:          cfg.RawRetention,
+		HourlyRetention:       cfg.HourlyRetention,
 	})
 	s.StartAsync()
 	log.Print("Job runner started")
@@ -64,6 +81,29 @@ func main() {
 		DB:                 db,
 		FridgeManager:      fm,
 		TemperatureManager: tm,
+		ContactManager:     cm,
+		AlertManager:       am,
+		DeviceManager:      dm,
+		SensorTokenManager: stm,
+		AdminCredentials: routes.AdminCredentials{
+			Username: cfg.AdminUsername,
+			Password: cfg.AdminPassword,
+		},
+		Events: hub,
 	})
 	log.Fatal(app.Listen(":" + cfg.HTTPPort))
 }
+
+// newNotifier creates the notifier that was selected in the config.
+func newNotifier(cfg config.Config) notify.Notifier {
+	switch cfg.Notifier {
+	case config.NotifierEmail:
+		return notify.NewEmailNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	case config.NotifierWebhook:
+		return notify.NewWebhookNotifier(cfg.WebhookURL)
+	case config.NotifierLog:
+		return notify.NewLogNotifier(nil)
+	default:
+		return sms.NewClient(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioPhoneNumber)
+	}
+}
@@ -0,0 +1,297 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+)
+
+// AlertKind is the type of problem that an alert is for.
+type AlertKind string
+
+const (
+	AlertKindNoData      AlertKind = "no_data"
+	AlertKindTemperature AlertKind = "temperature"
+	AlertKindHumidity    AlertKind = "humidity"
+)
+
+// AlertState is the state of an alert.
+type AlertState string
+
+const (
+	// AlertStateOpen means the problem is ongoing and no one has acknowledged it.
+	AlertStateOpen AlertState = "open"
+	// AlertStateAcknowledged means the problem is ongoing and someone is handling it.
+	AlertStateAcknowledged AlertState = "acknowledged"
+	// AlertStateResolved means the problem has gone away.
+	AlertStateResolved AlertState = "resolved"
+)
+
+type Alert struct {
+	ID       int64
+	FridgeID int64
+	Kind     AlertKind
+	// Channel is the channel of the fridge the alert is for. It is empty for the
+	// default channel or if the alert is for the aggregate of all channels.
+	Channel string
+	State   AlertState
+	// Message is the most recent message that was sent for the alert.
+	Message           string
+	NotificationCount int
+	CreatedAt         Time
+	LastNotifiedAt    Time
+	AcknowledgedAt    NullTime
+	ResolvedAt        NullTime
+}
+
+const alertColumns = `id, fridge_id, kind, channel, state, message, notification_count, created_at, last_notified_at, acknowledged_at, resolved_at`
+
+func scanAlert(row rowScanner) (Alert, error) {
+	var a Alert
+	err := row.Scan(
+		&a.ID,
+		&a.FridgeID,
+		&a.Kind,
+		&a.Channel,
+		&a.State,
+		&a.Message,
+		&a.NotificationCount,
+		&a.CreatedAt,
+		&a.LastNotifiedAt,
+		&a.AcknowledgedAt,
+		&a.ResolvedAt,
+	)
+	return a, err
+}
+
+type AlertManager struct {
+	db *sql.DB
+}
+
+func NewAlertManager(db *sql.DB) *AlertManager {
+	return &AlertManager{db}
+}
+
+// FindAllUnresolved returns all alerts that are not resolved, most recent first.
+func (am *AlertManager) FindAllUnresolved(ctx context.Context) ([]Alert, error) {
+	return am.findAll(
+		ctx,
+		"models.AlertManager.FindAllUnresolved",
+		`SELECT `+alertColumns+` FROM alerts WHERE state != ? ORDER BY created_at DESC`,
+		AlertStateResolved,
+	)
+}
+
+// FindAllUnresolvedByFridgeID returns all alerts for the fridge that are not resolved.
+func (am *AlertManager) FindAllUnresolvedByFridgeID(ctx context.Context, fridgeID int64) ([]Alert, error) {
+	return am.findAll(
+		ctx,
+		"models.AlertManager.FindAllUnresolvedByFridgeID",
+		`SELECT `+alertColumns+` FROM alerts WHERE fridge_id = ? AND state != ? ORDER BY created_at DESC`,
+		fridgeID,
+		AlertStateResolved,
+	)
+}
+
+func (am *AlertManager) findAll(ctx context.Context, op apierror.Op, query string, args ...any) ([]Alert, error) {
+	rows, err := resolveRunner(ctx, am.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve alerts",
+			op,
+		)
+	}
+
+	var alerts []Alert
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan alert row",
+				op,
+			)
+		}
+		alerts = append(alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over alert rows",
+			op,
+		)
+	}
+	return alerts, nil
+}
+
+func (am *AlertManager) FindOneByID(ctx context.Context, id int64) (Alert, error) {
+	const op = apierror.Op("models.AlertManager.FindOneByID")
+	row := resolveRunner(ctx, am.db).QueryRowContext(ctx, `SELECT `+alertColumns+` FROM alerts WHERE id = ?`, id)
+	a, err := scanAlert(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no alert found with id %d", id),
+			op,
+		)
+	} else if err != nil {
+		return a, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve alert",
+			op,
+		)
+	}
+	return a, nil
+}
+
+// InsertOne creates a new open alert. It is assumed that a notification is sent for it.
+func (am *AlertManager) InsertOne(ctx context.Context, fridgeID int64, kind AlertKind, channel, message string) (Alert, error) {
+	const op = apierror.Op("models.AlertManager.InsertOne")
+	row := requireTxn(ctx).
+		QueryRowContext(
+			ctx,
+			`INSERT INTO alerts(fridge_id, kind, channel, message) VALUES(?, ?, ?, ?) RETURNING `+alertColumns,
+			fridgeID,
+			kind,
+			channel,
+			message,
+		)
+	a, err := scanAlert(row)
+	if err != nil {
+		return a, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to insert alert row",
+			op,
+		)
+	}
+	return a, nil
+}
+
+// MarkNotified records that another notification was sent for the alert with the given message.
+func (am *AlertManager) MarkNotified(ctx context.Context, id int64, message string) (Alert, error) {
+	return am.update(
+		ctx,
+		"models.AlertManager.MarkNotified",
+		id,
+		`UPDATE alerts SET message = ?, notification_count = notification_count + 1, last_notified_at = datetime('now')
+			WHERE id = ? RETURNING `+alertColumns,
+		message,
+	)
+}
+
+// Acknowledge marks the alert as acknowledged which stops reminders from being sent.
+// Acknowledging an already acknowledged alert is a no-op. Acknowledging a resolved alert is a conflict.
+func (am *AlertManager) Acknowledge(ctx context.Context, id int64) (Alert, error) {
+	const op = apierror.Op("models.AlertManager.Acknowledge")
+	a, err := am.FindOneByID(ctx, id)
+	if err != nil {
+		return a, err
+	}
+	switch a.State {
+	case AlertStateAcknowledged:
+		return a, nil
+	case AlertStateResolved:
+		return a, apierror.New(
+			apierror.CodeConflict,
+			fmt.Sprintf("alert %d is already resolved", id),
+			op,
+		)
+	}
+	return am.update(
+		ctx,
+		op,
+		id,
+		`UPDATE alerts SET state = ?, acknowledged_at = datetime('now') WHERE id = ? RETURNING `+alertColumns,
+		AlertStateAcknowledged,
+	)
+}
+
+// Resolve marks the alert as resolved with the given message.
+func (am *AlertManager) Resolve(ctx context.Context, id int64, message string) (Alert, error) {
+	return am.update(
+		ctx,
+		"models.AlertManager.Resolve",
+		id,
+		`UPDATE alerts SET state = ?, message = ?, resolved_at = datetime('now') WHERE id = ? RETURNING `+alertColumns,
+		AlertStateResolved,
+		message,
+	)
+}
+
+// ResolveAllByFridgeID resolves all unresolved alerts of the fridge with the given message.
+// It returns the alerts that were resolved.
+func (am *AlertManager) ResolveAllByFridgeID(ctx context.Context, fridgeID int64, message string) ([]Alert, error) {
+	const op = apierror.Op("models.AlertManager.ResolveAllByFridgeID")
+	rows, err := requireTxn(ctx).QueryContext(
+		ctx,
+		`UPDATE alerts SET state = ?, message = ?, resolved_at = datetime('now') WHERE fridge_id = ? AND state != ?
+			RETURNING `+alertColumns,
+		AlertStateResolved,
+		message,
+		fridgeID,
+		AlertStateResolved,
+	)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to resolve alerts of fridge",
+			op,
+		)
+	}
+	defer rows.Close()
+
+	var alerts []Alert
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan alert row",
+				op,
+			)
+		}
+		alerts = append(alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over resolved alert rows",
+			op,
+		)
+	}
+	return alerts, nil
+}
+
+// update runs an update query for the alert with the given id. The query must use id as the last parameter.
+func (am *AlertManager) update(ctx context.Context, op apierror.Op, id int64, query string, args ...any) (Alert, error) {
+	args = append(args, id)
+	row := requireTxn(ctx).QueryRowContext(ctx, query, args...)
+	a, err := scanAlert(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no alert found with id %d", id),
+			op,
+		)
+	} else if err != nil {
+		return a, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to update alert row",
+			op,
+		)
+	}
+	return a, nil
+}
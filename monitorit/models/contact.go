@@ -0,0 +1,319 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+)
+
+type Contact struct {
+	ID   int64
+	Name string
+	// Address is where alerts are sent, ex: a phone number or email address
+	// depending on the notifier being used.
+	Address string
+}
+
+// FridgeContact is a contact that is associated with a fridge.
+type FridgeContact struct {
+	Contact
+	FridgeID int64
+	// EscalationLevel determines the order contacts are notified in, lower levels are notified first.
+	EscalationLevel int
+}
+
+type ContactManager struct {
+	db *sql.DB
+}
+
+func NewContactManager(db *sql.DB) *ContactManager {
+	return &ContactManager{db}
+}
+
+func (cm *ContactManager) FindOneByID(ctx context.Context, id int64) (Contact, error) {
+	const op = apierror.Op("models.ContactManager.FindOneByID")
+	row := resolveRunner(ctx, cm.db).QueryRowContext(ctx, `SELECT id, name, address FROM contacts WHERE id = ?`, id)
+
+	var c Contact
+	err := row.Scan(
+		&c.ID,
+		&c.Name,
+		&c.Address,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no contact found with id %d", id),
+			op,
+		)
+	} else if err != nil {
+		return c, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve contact",
+			op,
+		)
+	}
+	return c, nil
+}
+
+func (cm *ContactManager) FindAllByFridgeID(ctx context.Context, fridgeID int64) ([]FridgeContact, error) {
+	const op = apierror.Op("models.ContactManager.FindAllByFridgeID")
+	rows, err := resolveRunner(ctx, cm.db).
+		QueryContext(
+			ctx,
+			`SELECT c.id, c.name, c.address, fc.fridge_id, fc.escalation_level FROM contacts c
+				INNER JOIN fridge_contacts fc ON fc.contact_id = c.id
+				WHERE fc.fridge_id = ?
+				ORDER BY fc.escalation_level, c.id`,
+			fridgeID,
+		)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve contacts",
+			op,
+		)
+	}
+
+	var contacts []FridgeContact
+	for rows.Next() {
+		var fc FridgeContact
+		err := rows.Scan(
+			&fc.ID,
+			&fc.Name,
+			&fc.Address,
+			&fc.FridgeID,
+			&fc.EscalationLevel,
+		)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan contact row",
+				op,
+			)
+		}
+		contacts = append(contacts, fc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over contact rows",
+			op,
+		)
+	}
+	return contacts, nil
+}
+
+func (cm *ContactManager) FindOneByFridgeID(ctx context.Context, fridgeID, contactID int64) (FridgeContact, error) {
+	const op = apierror.Op("models.ContactManager.FindOneByFridgeID")
+	row := resolveRunner(ctx, cm.db).
+		QueryRowContext(
+			ctx,
+			`SELECT c.id, c.name, c.address, fc.fridge_id, fc.escalation_level FROM contacts c
+				INNER JOIN fridge_contacts fc ON fc.contact_id = c.id
+				WHERE fc.fridge_id = ? AND fc.contact_id = ?`,
+			fridgeID,
+			contactID,
+		)
+
+	var fc FridgeContact
+	err := row.Scan(
+		&fc.ID,
+		&fc.Name,
+		&fc.Address,
+		&fc.FridgeID,
+		&fc.EscalationLevel,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fc, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no contact found with id %d for fridge %d", contactID, fridgeID),
+			op,
+		)
+	} else if err != nil {
+		return fc, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve contact",
+			op,
+		)
+	}
+	return fc, nil
+}
+
+// CountFridges returns the number of fridges the contact is a contact of.
+func (cm *ContactManager) CountFridges(ctx context.Context, contactID int64) (int, error) {
+	const op = apierror.Op("models.ContactManager.CountFridges")
+	var count int
+	err := resolveRunner(ctx, cm.db).
+		QueryRowContext(ctx, `SELECT count(*) FROM fridge_contacts WHERE contact_id = ?`, contactID).
+		Scan(&count)
+	if err != nil {
+		return 0, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to count fridges of contact",
+			op,
+		)
+	}
+	return count, nil
+}
+
+func (cm *ContactManager) InsertOne(ctx context.Context, contact Contact) (Contact, error) {
+	const op = apierror.Op("models.ContactManager.InsertOne")
+	var newContact Contact
+	err := requireTxn(ctx).
+		QueryRowContext(
+			ctx,
+			`INSERT INTO contacts(name, address) VALUES(?, ?) RETURNING id, name, address`,
+			contact.Name,
+			contact.Address,
+		).
+		Scan(
+			&newContact.ID,
+			&newContact.Name,
+			&newContact.Address,
+		)
+	if err != nil {
+		return newContact, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to insert contact row",
+			op,
+		)
+	}
+	return newContact, nil
+}
+
+// AddToFridge associates the contact with the fridge so that it receives alerts for the fridge.
+func (cm *ContactManager) AddToFridge(ctx context.Context, fridgeID, contactID int64, escalationLevel int) (FridgeContact, error) {
+	const op = apierror.Op("models.ContactManager.AddToFridge")
+	_, err := requireTxn(ctx).
+		ExecContext(
+			ctx,
+			`INSERT INTO fridge_contacts(fridge_id, contact_id, escalation_level) VALUES(?, ?, ?)`,
+			fridgeID,
+			contactID,
+			escalationLevel,
+		)
+	if isUniqueViolation(err) {
+		return FridgeContact{}, apierror.Wrap(
+			err,
+			apierror.CodeConflict,
+			fmt.Sprintf("contact %d is already a contact of fridge %d", contactID, fridgeID),
+			op,
+		)
+	} else if err != nil {
+		return FridgeContact{}, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to insert fridge contact row",
+			op,
+		)
+	}
+	return cm.FindOneByFridgeID(ctx, fridgeID, contactID)
+}
+
+type PartialContact struct {
+	Name    string
+	Address string
+}
+
+func (cm *ContactManager) UpdateOne(ctx context.Context, id int64, contact PartialContact) error {
+	const op = apierror.Op("models.ContactManager.UpdateOne")
+	var fields []string
+	var args []any
+	if contact.Name != "" {
+		fields = append(fields, "name")
+		args = append(args, contact.Name)
+	}
+	if contact.Address != "" {
+		fields = append(fields, "address")
+		args = append(args, contact.Address)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString("UPDATE contacts SET ")
+	for i, field := range fields {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString(field)
+		query.WriteString(" = ?")
+	}
+	query.WriteString(" WHERE id = ?")
+	args = append(args, id)
+
+	if _, err := requireTxn(ctx).ExecContext(ctx, query.String(), args...); err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to update contact row",
+			op,
+		)
+	}
+	return nil
+}
+
+func (cm *ContactManager) UpdateEscalationLevel(ctx context.Context, fridgeID, contactID int64, escalationLevel int) error {
+	const op = apierror.Op("models.ContactManager.UpdateEscalationLevel")
+	_, err := requireTxn(ctx).
+		ExecContext(
+			ctx,
+			`UPDATE fridge_contacts SET escalation_level = ? WHERE fridge_id = ? AND contact_id = ?`,
+			escalationLevel,
+			fridgeID,
+			contactID,
+		)
+	if err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to update fridge contact row",
+			op,
+		)
+	}
+	return nil
+}
+
+// RemoveFromFridge removes the association between the contact and the fridge.
+// If the contact is no longer associated with any fridges it is deleted.
+func (cm *ContactManager) RemoveFromFridge(ctx context.Context, fridgeID, contactID int64) error {
+	const op = apierror.Op("models.ContactManager.RemoveFromFridge")
+	txn := requireTxn(ctx)
+	_, err := txn.ExecContext(ctx, `DELETE FROM fridge_contacts WHERE fridge_id = ? AND contact_id = ?`, fridgeID, contactID)
+	if err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to delete fridge contact row",
+			op,
+		)
+	}
+	_, err = txn.ExecContext(
+		ctx,
+		`DELETE FROM contacts WHERE id = ? AND NOT EXISTS (SELECT 1 FROM fridge_contacts WHERE contact_id = ?)`,
+		contactID,
+		contactID,
+	)
+	if err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to delete contact row",
+			op,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+)
+
+// Device is a physical sensor that sends temperatures for a fridge.
+type Device struct {
+	ID int64
+	// Serial uniquely identifies the device, ex: the Raspberry Pi serial or ESP32 MAC address.
+	Serial   string
+	Firmware string
+	// FridgeID is the fridge the device last sent a temperature for.
+	// It is nil if the device has not been assigned to a fridge.
+	FridgeID   *int64
+	CreatedAt  Time
+	LastSeenAt Time
+}
+
+const deviceColumns = `id, serial, firmware, fridge_id, created_at, last_seen_at`
+
+func scanDevice(row rowScanner) (Device, error) {
+	var d Device
+	err := row.Scan(
+		&d.ID,
+		&d.Serial,
+		&d.Firmware,
+		&d.FridgeID,
+		&d.CreatedAt,
+		&d.LastSeenAt,
+	)
+	return d, err
+}
+
+type DeviceManager struct {
+	db *sql.DB
+}
+
+func NewDeviceManager(db *sql.DB) *DeviceManager {
+	return &DeviceManager{db}
+}
+
+func (dm *DeviceManager) FindAll(ctx context.Context) ([]Device, error) {
+	return dm.findAll(
+		ctx,
+		"models.DeviceManager.FindAll",
+		`SELECT `+deviceColumns+` FROM devices ORDER BY id`,
+	)
+}
+
+func (dm *DeviceManager) FindAllByFridgeID(ctx context.Context, fridgeID int64) ([]Device, error) {
+	return dm.findAll(
+		ctx,
+		"models.DeviceManager.FindAllByFridgeID",
+		`SELECT `+deviceColumns+` FROM devices WHERE fridge_id = ? ORDER BY id`,
+		fridgeID,
+	)
+}
+
+func (dm *DeviceManager) FindOneByID(ctx context.Context, id int64) (Device, error) {
+	const op = apierror.Op("models.DeviceManager.FindOneByID")
+	row := resolveRunner(ctx, dm.db).
+		QueryRowContext(ctx, `SELECT `+deviceColumns+` FROM devices WHERE id = ?`, id)
+	d, err := scanDevice(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no device found with id %d", id),
+			op,
+		)
+	} else if err != nil {
+		return d, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			fmt.Sprintf("failed to retrieve device with id %d", id),
+			op,
+		)
+	}
+	return d, nil
+}
+
+// Record records that the device with the given serial sent a temperature for the fridge.
+// The device is created if it does not exist. Otherwise, it is assigned to the fridge
+// and its last seen time is updated. The firmware is only updated if it is not empty.
+func (dm *DeviceManager) Record(ctx context.Context, serial, firmware string, fridgeID int64) (Device, error) {
+	const op = apierror.Op("models.DeviceManager.Record")
+	row := requireTxn(ctx).
+		QueryRowContext(
+			ctx,
+			`INSERT INTO devices(serial, firmware, fridge_id) VALUES(?, ?, ?)
+			ON CONFLICT(serial) DO UPDATE SET
+				firmware = CASE WHEN excluded.firmware = '' THEN firmware ELSE excluded.firmware END,
+				fridge_id = excluded.fridge_id,
+				last_seen_at = datetime('now')
+			RETURNING `+deviceColumns,
+			serial,
+			firmware,
+			fridgeID,
+		)
+	d, err := scanDevice(row)
+	if err != nil {
+		return d, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			fmt.Sprintf("failed to record device %q", serial),
+			op,
+		)
+	}
+	return d, nil
+}
+
+func (dm *DeviceManager) findAll(ctx context.Context, op apierror.Op, query string, args ...any) ([]Device, error) {
+	rows, err := resolveRunner(ctx, dm.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve devices",
+			op,
+		)
+	}
+
+	var devices []Device
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan device row",
+				op,
+			)
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over device rows",
+			op,
+		)
+	}
+	return devices, nil
+}
@@ -6,10 +6,40 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
 )
 
+// Defaults for the alert thresholds of a fridge.
+const (
+	DefaultAlertSampleCount = 3
+	DefaultMaxSilence       = 30 * time.Minute
+)
+
+// ChannelAggregate is how the temperatures of each channel of a fridge are
+// combined when checking if the fridge is within its safe range.
+type ChannelAggregate string
+
+const (
+	// ChannelAggregateAny checks each channel separately and alerts if any channel is outside the safe range.
+	ChannelAggregateAny ChannelAggregate = "any"
+	// ChannelAggregateMax checks the maximum of the channels.
+	ChannelAggregateMax ChannelAggregate = "max"
+	// ChannelAggregateMean checks the mean of the channels.
+	ChannelAggregateMean ChannelAggregate = "mean"
+)
+
+// Valid returns whether ca is one of the known channel aggregates.
+func (ca ChannelAggregate) Valid() bool {
+	switch ca {
+	case ChannelAggregateAny, ChannelAggregateMax, ChannelAggregateMean:
+		return true
+	default:
+		return false
+	}
+}
+
 type Fridge struct {
 	ID            int64
 	Name          string
@@ -17,6 +47,49 @@ type Fridge struct {
 	MinTemp       float64
 	MaxTemp       float64
 	AlertsEnabled bool
+	// AlertSampleCount is the number of consecutive readings that must be outside
+	// of the safe range before an alert is triggered.
+	AlertSampleCount int
+	// MaxSilence is the maximum amount of time without a reading before an alert is triggered.
+	MaxSilence time.Duration
+	// TempHysteresis is how far inside the safe range the temperature must be
+	// before an alert is resolved. This prevents alerts from flapping when the
+	// temperature is hovering around MinTemp or MaxTemp.
+	TempHysteresis float64
+	// MinHumidity and MaxHumidity are the safe humidity range of the fridge.
+	// If either is nil, humidity is not checked in that direction.
+	MinHumidity *float64
+	MaxHumidity *float64
+	// ChannelAggregate is how the channels of the fridge are combined when checking
+	// the temperature and humidity. Every channel is always checked for missing data.
+	ChannelAggregate ChannelAggregate
+	// ArchivedAt is when the fridge was archived. Archived fridges are no longer in use
+	// but are kept so their history is available.
+	ArchivedAt NullTime
+}
+
+const fridgeColumns = `id, name, description, min_temp, max_temp, alerts_enabled, alert_sample_count, max_silence_seconds, temp_hysteresis, min_humidity, max_humidity, channel_aggregate, archived_at`
+
+func scanFridge(row rowScanner) (Fridge, error) {
+	var f Fridge
+	var maxSilenceSeconds int64
+	err := row.Scan(
+		&f.ID,
+		&f.Name,
+		&f.Description,
+		&f.MinTemp,
+		&f.MaxTemp,
+		&f.AlertsEnabled,
+		&f.AlertSampleCount,
+		&maxSilenceSeconds,
+		&f.TempHysteresis,
+		&f.MinHumidity,
+		&f.MaxHumidity,
+		&f.ChannelAggregate,
+		&f.ArchivedAt,
+	)
+	f.MaxSilence = time.Duration(maxSilenceSeconds) * time.Second
+	return f, err
 }
 
 type FridgeManager struct {
@@ -27,10 +100,27 @@ func NewFridgeManager(db *sql.DB) *FridgeManager {
 	return &FridgeManager{db}
 }
 
+// FindAll returns all fridges that are not archived.
 func (fm *FridgeManager) FindAll(ctx context.Context) ([]Fridge, error) {
-	const op = apierror.Op("models.FridgeManager.FindAll")
+	return fm.findAll(
+		ctx,
+		"models.FridgeManager.FindAll",
+		`SELECT `+fridgeColumns+` FROM fridges WHERE archived_at IS NULL`,
+	)
+}
+
+// FindAllArchived returns all archived fridges, most recently archived first.
+func (fm *FridgeManager) FindAllArchived(ctx context.Context) ([]Fridge, error) {
+	return fm.findAll(
+		ctx,
+		"models.FridgeManager.FindAllArchived",
+		`SELECT `+fridgeColumns+` FROM fridges WHERE archived_at IS NOT NULL ORDER BY archived_at DESC, id`,
+	)
+}
+
+func (fm *FridgeManager) findAll(ctx context.Context, op apierror.Op, query string, args ...any) ([]Fridge, error) {
 	r := resolveRunner(ctx, fm.db)
-	rows, err := r.QueryContext(ctx, `SELECT id, name, description, min_temp, max_temp, alerts_enabled FROM fridges`)
+	rows, err := r.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, apierror.Wrap(
 			err,
@@ -39,18 +129,11 @@ func (fm *FridgeManager) FindAll(ctx context.Context) ([]Fridge, error) {
 			op,
 		)
 	}
+	defer rows.Close()
 
 	var fridges []Fridge
 	for rows.Next() {
-		var f Fridge
-		err := rows.Scan(
-			&f.ID,
-			&f.Name,
-			&f.Description,
-			&f.MinTemp,
-			&f.MaxTemp,
-			&f.AlertsEnabled,
-		)
+		f, err := scanFridge(rows)
 		if err != nil {
 			return nil, apierror.Wrap(
 				err,
@@ -75,17 +158,9 @@ func (fm *FridgeManager) FindAll(ctx context.Context) ([]Fridge, error) {
 func (fm *FridgeManager) FindOneByID(ctx context.Context, id int64) (Fridge, error) {
 	const op = apierror.Op("models.FridgeManager.FindOneByID")
 	r := resolveRunner(ctx, fm.db)
-	row := r.QueryRowContext(ctx, `SELECT id, name, description, min_temp, max_temp, alerts_enabled FROM fridges WHERE id = ?`, id)
+	row := r.QueryRowContext(ctx, `SELECT `+fridgeColumns+` FROM fridges WHERE id = ?`, id)
 
-	var f Fridge
-	err := row.Scan(
-		&f.ID,
-		&f.Name,
-		&f.Description,
-		&f.MinTemp,
-		&f.MaxTemp,
-		&f.AlertsEnabled,
-	)
+	f, err := scanFridge(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return f, apierror.New(
 			apierror.CodeRecordNotFound,
@@ -105,27 +180,33 @@ func (fm *FridgeManager) FindOneByID(ctx context.Context, id int64) (Fridge, err
 
 func (fm *FridgeManager) InsertOne(ctx context.Context, fridge Fridge) (Fridge, error) {
 	const op = apierror.Op("models.FridgeManager.InsertOne")
-	var newFridge Fridge
-	err := requireTxn(ctx).
+	row := requireTxn(ctx).
 		QueryRowContext(
 			ctx,
-			`INSERT INTO fridges(name, description, min_temp, max_temp, alerts_enabled) VALUES(?, ?, ?, ?, ?)
-				RETURNING id, name, description, min_temp, max_temp, alerts_enabled`,
+			`INSERT INTO fridges(name, description, min_temp, max_temp, alerts_enabled, alert_sample_count, max_silence_seconds, temp_hysteresis, min_humidity, max_humidity, channel_aggregate)
+				VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				RETURNING `+fridgeColumns,
 			fridge.Name,
 			fridge.Description,
 			fridge.MinTemp,
 			fridge.MaxTemp,
 			fridge.AlertsEnabled,
-		).
-		Scan(
-			&newFridge.ID,
-			&newFridge.Name,
-			&newFridge.Description,
-			&newFridge.MinTemp,
-			&newFridge.MaxTemp,
-			&newFridge.AlertsEnabled,
+			fridge.AlertSampleCount,
+			int64(fridge.MaxSilence/time.Second),
+			fridge.TempHysteresis,
+			fridge.MinHumidity,
+			fridge.MaxHumidity,
+			fridge.ChannelAggregate,
 		)
-	if err != nil {
+	newFridge, err := scanFridge(row)
+	if isUniqueViolation(err) {
+		return newFridge, apierror.Wrap(
+			err,
+			apierror.CodeConflict,
+			fmt.Sprintf("a fridge named %q already exists", fridge.Name),
+			op,
+		)
+	} else if err != nil {
 		return newFridge, apierror.Wrap(
 			err,
 			apierror.CodeDatabase,
@@ -137,11 +218,21 @@ func (fm *FridgeManager) InsertOne(ctx context.Context, fridge Fridge) (Fridge,
 }
 
 type PartialFridge struct {
-	Name          string
-	Description   *string
-	MinTemp       *float64
-	MaxTemp       *float64
-	AlertsEnabled *bool
+	Name             string
+	Description      *string
+	MinTemp          *float64
+	MaxTemp          *float64
+	AlertsEnabled    *bool
+	AlertSampleCount *int
+	MaxSilence       *time.Duration
+	TempHysteresis   *float64
+	MinHumidity      *float64
+	MaxHumidity      *float64
+	// ClearMinHumidity and ClearMaxHumidity remove the bound from the safe humidity range.
+	// They take precedence over MinHumidity and MaxHumidity. If both are cleared humidity is no longer checked.
+	ClearMinHumidity bool
+	ClearMaxHumidity bool
+	ChannelAggregate *ChannelAggregate
 }
 
 func (fm *FridgeManager) UpdateOne(ctx context.Context, id int64, fridge PartialFridge) (Fridge, error) {
@@ -168,6 +259,36 @@ func (fm *FridgeManager) UpdateOne(ctx context.Context, id int64, fridge Partial
 		fields = append(fields, "alerts_enabled")
 		args = append(args, *fridge.AlertsEnabled)
 	}
+	if fridge.AlertSampleCount != nil {
+		fields = append(fields, "alert_sample_count")
+		args = append(args, *fridge.AlertSampleCount)
+	}
+	if fridge.MaxSilence != nil {
+		fields = append(fields, "max_silence_seconds")
+		args = append(args, int64(*fridge.MaxSilence/time.Second))
+	}
+	if fridge.TempHysteresis != nil {
+		fields = append(fields, "temp_hysteresis")
+		args = append(args, *fridge.TempHysteresis)
+	}
+	if fridge.ClearMinHumidity {
+		fields = append(fields, "min_humidity")
+		args = append(args, nil)
+	} else if fridge.MinHumidity != nil {
+		fields = append(fields, "min_humidity")
+		args = append(args, *fridge.MinHumidity)
+	}
+	if fridge.ClearMaxHumidity {
+		fields = append(fields, "max_humidity")
+		args = append(args, nil)
+	} else if fridge.MaxHumidity != nil {
+		fields = append(fields, "max_humidity")
+		args = append(args, *fridge.MaxHumidity)
+	}
+	if fridge.ChannelAggregate != nil {
+		fields = append(fields, "channel_aggregate")
+		args = append(args, *fridge.ChannelAggregate)
+	}
 	// If nothing to update just fetch and return the fridge
 	if len(args) == 0 {
 		return fm.FindOneByID(ctx, id)
@@ -182,21 +303,24 @@ func (fm *FridgeManager) UpdateOne(ctx context.Context, id int64, fridge Partial
 		query.WriteString(field)
 		query.WriteString(" = ?")
 	}
-	query.WriteString(" WHERE id = ? RETURNING id, name, description, min_temp, max_temp, alerts_enabled")
+	query.WriteString(" WHERE id = ? RETURNING " + fridgeColumns)
 	args = append(args, id)
 
-	var newFridge Fridge
-	err := requireTxn(ctx).
-		QueryRowContext(ctx, query.String(), args...).
-		Scan(
-			&newFridge.ID,
-			&newFridge.Name,
-			&newFridge.Description,
-			&newFridge.MinTemp,
-			&newFridge.MaxTemp,
-			&newFridge.AlertsEnabled,
+	newFridge, err := scanFridge(requireTxn(ctx).QueryRowContext(ctx, query.String(), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return newFridge, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no fridge found with id %d", id),
+			op,
 		)
-	if err != nil {
+	} else if isUniqueViolation(err) {
+		return newFridge, apierror.Wrap(
+			err,
+			apierror.CodeConflict,
+			fmt.Sprintf("a fridge named %q already exists", fridge.Name),
+			op,
+		)
+	} else if err != nil {
 		return newFridge, apierror.Wrap(
 			err,
 			apierror.CodeDatabase,
@@ -206,3 +330,77 @@ func (fm *FridgeManager) UpdateOne(ctx context.Context, id int64, fridge Partial
 	}
 	return newFridge, nil
 }
+
+// Archive marks the fridge as archived. Archiving an already archived fridge is a no-op.
+func (fm *FridgeManager) Archive(ctx context.Context, id int64) (Fridge, error) {
+	const op = apierror.Op("models.FridgeManager.Archive")
+	row := requireTxn(ctx).QueryRowContext(
+		ctx,
+		`UPDATE fridges SET archived_at = COALESCE(archived_at, datetime('now')) WHERE id = ? RETURNING `+fridgeColumns,
+		id,
+	)
+	f, err := scanFridge(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no fridge found with id %d", id),
+			op,
+		)
+	} else if err != nil {
+		return f, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to archive fridge",
+			op,
+		)
+	}
+	return f, nil
+}
+
+// DeleteOne permanently deletes the fridge along with its temperatures, alerts, and sensor tokens.
+// Contacts and devices are kept but are no longer assigned to the fridge.
+func (fm *FridgeManager) DeleteOne(ctx context.Context, id int64) error {
+	const op = apierror.Op("models.FridgeManager.DeleteOne")
+	txn := requireTxn(ctx)
+	// Foreign keys aren't enforced so rows that reference the fridge have to be deleted manually.
+	statements := []struct {
+		query string
+		table string
+	}{
+		{`DELETE FROM temperatures WHERE fridge_id = ?`, "temperatures"},
+		{`DELETE FROM temperatures_hourly WHERE fridge_id = ?`, "temperatures_hourly"},
+		{`DELETE FROM temperatures_daily WHERE fridge_id = ?`, "temperatures_daily"},
+		{`DELETE FROM alerts WHERE fridge_id = ?`, "alerts"},
+		{`DELETE FROM fridge_contacts WHERE fridge_id = ?`, "fridge_contacts"},
+		{`DELETE FROM sensor_tokens WHERE fridge_id = ?`, "sensor_tokens"},
+		{`UPDATE devices SET fridge_id = NULL WHERE fridge_id = ?`, "devices"},
+	}
+	for _, stmt := range statements {
+		if _, err := txn.ExecContext(ctx, stmt.query, id); err != nil {
+			return apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				fmt.Sprintf("failed to delete %s rows of fridge", stmt.table),
+				op,
+			)
+		}
+	}
+
+	result, err := txn.ExecContext(ctx, `DELETE FROM fridges WHERE id = ?`, id)
+	if err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to delete fridge row",
+			op,
+		)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no fridge found with id %d", id),
+			op,
+		)
+	}
+	return nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 type txnKey struct{}
@@ -26,6 +29,15 @@ func txnFromContext(ctx context.Context) *sql.Tx {
 	return txn
 }
 
+// isUniqueViolation returns whether err was caused by violating a UNIQUE or PRIMARY KEY constraint.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
+
 // runner represents functionality for working with a DB.
 // It is a way to generalize sql.DB and sql.Tx.
 type runner interface {
@@ -33,6 +45,12 @@ type runner interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
+// rowScanner represents a single row that can be scanned.
+// It is a way to generalize sql.Row and sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 func resolveRunner(ctx context.Context, db *sql.DB) runner {
 	txn := txnFromContext(ctx)
 	if txn == nil {
@@ -74,3 +92,26 @@ func (t *Time) Scan(value any) error {
 func (t Time) Value() (driver.Value, error) {
 	return t.Format(timeFormatSQLite), nil
 }
+
+// NullTime is a Time that may be null. It can be used as a scan destination
+// for nullable datetimes stored in sqlite text columns.
+type NullTime struct {
+	Time  Time
+	Valid bool
+}
+
+func (nt *NullTime) Scan(value any) error {
+	if value == nil {
+		nt.Time, nt.Valid = Time{}, false
+		return nil
+	}
+	nt.Valid = true
+	return nt.Time.Scan(value)
+}
+
+func (nt NullTime) Value() (driver.Value, error) {
+	if !nt.Valid {
+		return nil, nil
+	}
+	return nt.Time.Value()
+}
@@ -3,15 +3,27 @@ package models
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
 )
 
 type Temperature struct {
-	ID        int64
-	Value     float64
-	Humidity  float64
-	FridgeID  int64
+	ID    int64
+	Value float64
+	// Humidity is the relative humidity in %. It is nil if the sensor can't measure humidity.
+	Humidity *float64
+	// Pressure is the air pressure in hPa. It is nil if the sensor can't measure pressure.
+	Pressure *float64
+	FridgeID int64
+	// Channel is the sensor channel of the fridge, ex: a probe on the top shelf.
+	// It is empty for fridges with a single sensor.
+	Channel string
+	// DeviceID is the device that sent the temperature, if known.
+	DeviceID  *int64
 	CreatedAt Time
 }
 
@@ -47,6 +59,60 @@ func (t Temperature) Status(minTemp, maxTemp float64) TemperatureStatus {
 	}
 }
 
+type HumidityStatus uint8
+
+const (
+	HumidityNormal HumidityStatus = iota
+	HumidityTooLow
+	HumidityTooHigh
+)
+
+func (hs HumidityStatus) String() string {
+	switch hs {
+	case HumidityNormal:
+		return "normal"
+	case HumidityTooLow:
+		return "too_low"
+	case HumidityTooHigh:
+		return "too_high"
+	default:
+		panic("impossible: unknown HumidityStatus")
+	}
+}
+
+// HumidityStatus returns the status of the humidity relative to the given range.
+// If a bound is nil, the humidity is never considered outside of it.
+// If the temperature has no humidity, it is always considered normal.
+func (t Temperature) HumidityStatus(minHumidity, maxHumidity *float64) HumidityStatus {
+	switch {
+	case t.Humidity == nil:
+		return HumidityNormal
+	case minHumidity != nil && *t.Humidity < *minHumidity:
+		return HumidityTooLow
+	case maxHumidity != nil && *t.Humidity > *maxHumidity:
+		return HumidityTooHigh
+	default:
+		return HumidityNormal
+	}
+}
+
+const temperatureColumns = `id, value, humidity, pressure, fridge_id, channel, device_id, created_at`
+
+func scanTemperature(row rowScanner) (Temperature, error) {
+	var t Temperature
+	err := row.Scan(
+		&t.ID,
+		&t.Value,
+		&t.Humidity,
+		&t.Pressure,
+		&t.FridgeID,
+		&t.Channel,
+		&t.DeviceID,
+		&t.CreatedAt,
+	)
+	return t, err
+}
+
 type TemperatureManager struct {
 	db *sql.DB
 }
@@ -56,33 +122,469 @@ func NewTemperatureManager(db *sql.DB) *TemperatureManager {
 }
 
 func (tm *TemperatureManager) FindMostRecentByFridgeID(ctx context.Context, fridgeID int64, limit int) ([]Temperature, error) {
-	const op = apierror.Op("models.TemperatureManager.FindMostRecentByFridgeID")
+	return tm.findAll(
+		ctx,
+		"models.TemperatureManager.FindMostRecentByFridgeID",
+		`SELECT `+temperatureColumns+` FROM temperatures WHERE fridge_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		fridgeID,
+		limit,
+	)
+}
+
+func (tm *TemperatureManager) FindMostRecentByFridgeChannel(ctx context.Context, fridgeID int64, channel string, limit int) ([]Temperature, error) {
+	return tm.findAll(
+		ctx,
+		"models.TemperatureManager.FindMostRecentByFridgeChannel",
+		`SELECT `+temperatureColumns+` FROM temperatures WHERE fridge_id = ? AND channel = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		fridgeID,
+		channel,
+		limit,
+	)
+}
+
+// FindChannelsByFridgeID returns the channels of the fridge that have a temperature since the given time.
+func (tm *TemperatureManager) FindChannelsByFridgeID(ctx context.Context, fridgeID int64, since time.Time) ([]string, error) {
+	const op = apierror.Op("models.TemperatureManager.FindChannelsByFridgeID")
 	rows, err := resolveRunner(ctx, tm.db).
 		QueryContext(
 			ctx,
-			`SELECT id, value, humidity, fridge_id, created_at FROM temperatures WHERE fridge_id = ? ORDER BY created_at DESC LIMIT ?`,
+			`SELECT DISTINCT channel FROM temperatures WHERE fridge_id = ? AND created_at >= ? ORDER BY channel`,
 			fridgeID,
-			limit,
+			Time{since.UTC()},
 		)
 	if err != nil {
 		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve temperature channels",
+			op,
+		)
+	}
+
+	var channels []string
+	for rows.Next() {
+		var channel string
+		if err := rows.Scan(&channel); err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan temperature channel",
+				op,
+			)
+		}
+		channels = append(channels, channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over temperature channels",
+			op,
+		)
+	}
+	return channels, nil
+}
+
+func (tm *TemperatureManager) FindMostRecentByDeviceID(ctx context.Context, deviceID int64, limit int) ([]Temperature, error) {
+	return tm.findAll(
+		ctx,
+		"models.TemperatureManager.FindMostRecentByDeviceID",
+		`SELECT `+temperatureColumns+` FROM temperatures WHERE device_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		deviceID,
+		limit,
+	)
+}
+
+// TemperatureCursor identifies the position of a temperature in a list of temperatures.
+// It is used to continue a query after the temperature.
+type TemperatureCursor struct {
+	CreatedAt Time
+	ID        int64
+}
+
+// TemperatureQuery specifies which temperatures to find for a fridge.
+type TemperatureQuery struct {
+	FridgeID int64
+	// Channel, if set, only finds temperatures for the channel.
+	Channel *string
+	// From is the inclusive start of the time range. If zero, there is no start.
+	From time.Time
+	// To is the exclusive end of the time range. If zero, there is no end.
+	To time.Time
+	// After, if set, only finds temperatures that come after the cursor in the order of the query.
+	After *TemperatureCursor
+	// Descending orders temperatures from newest to oldest instead of oldest to newest.
+	Descending bool
+	Limit      int
+}
+
+// FindByFridgeID finds the temperatures for a fridge that match the query.
+func (tm *TemperatureManager) FindByFridgeID(ctx context.Context, q TemperatureQuery) ([]Temperature, error) {
+	var query strings.Builder
+	args := []any{q.FridgeID}
+	query.WriteString(`SELECT ` + temperatureColumns + ` FROM temperatures WHERE fridge_id = ?`)
+	if q.Channel != nil {
+		query.WriteString(" AND channel = ?")
+		args = append(args, *q.Channel)
+	}
+	if !q.From.IsZero() {
+		query.WriteString(" AND created_at >= ?")
+		args = append(args, Time{q.From.UTC()})
+	}
+	if !q.To.IsZero() {
+		query.WriteString(" AND created_at < ?")
+		args = append(args, Time{q.To.UTC()})
+	}
+	direction := "ASC"
+	if q.Descending {
+		direction = "DESC"
+	}
+	if q.After != nil {
+		// Use a row value comparison so that temperatures with the same created_at are handled correctly
+		if q.Descending {
+			query.WriteString(" AND (created_at, id) < (?, ?)")
+		} else {
+			query.WriteString(" AND (created_at, id) > (?, ?)")
+		}
+		args = append(args, q.After.CreatedAt, q.After.ID)
+	}
+	fmt.Fprintf(&query, " ORDER BY created_at %s, id %s LIMIT ?", direction, direction)
+	args = append(args, q.Limit)
+	return tm.findAll(ctx, "models.TemperatureManager.FindByFridgeID", query.String(), args...)
+}
+
+// EachByFridgeID calls fn for each temperature of the fridge in the time range [from, to), oldest first.
+// A zero from or to means the range is unbounded in that direction. Temperatures are read from the
+// database as they are iterated over so this can be used for large numbers of temperatures.
+// If fn returns an error, iteration stops and the error is returned.
+func (tm *TemperatureManager) EachByFridgeID(ctx context.Context, fridgeID int64, from, to time.Time, fn func(Temperature) error) error {
+	const op = apierror.Op("models.TemperatureManager.EachByFridgeID")
+	var query strings.Builder
+	args := []any{fridgeID}
+	query.WriteString(`SELECT ` + temperatureColumns + ` FROM temperatures WHERE fridge_id = ?`)
+	if !from.IsZero() {
+		query.WriteString(" AND created_at >= ?")
+		args = append(args, Time{from.UTC()})
+	}
+	if !to.IsZero() {
+		query.WriteString(" AND created_at < ?")
+		args = append(args, Time{to.UTC()})
+	}
+	query.WriteString(" ORDER BY created_at, id")
+
+	rows, err := resolveRunner(ctx, tm.db).QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return apierror.Wrap(
 			err,
 			apierror.CodeDatabase,
 			"failed to retrieve temperatures",
 			op,
 		)
 	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanTemperature(rows)
+		if err != nil {
+			return apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan temperature row",
+				op,
+			)
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over temperature rows",
+			op,
+		)
+	}
+	return nil
+}
 
-	var temperatures []Temperature
+// TemperatureStats are aggregate statistics for the temperatures in a time bucket.
+type TemperatureStats struct {
+	// BucketStart is the start of the time bucket the stats are for.
+	BucketStart time.Time
+	Count       int
+	MinValue    float64
+	MaxValue    float64
+	MeanValue   float64
+	StdDevValue float64
+	// HumidityCount is the number of temperatures that had a humidity. If it is 0
+	// the other humidity stats are meaningless.
+	HumidityCount  int
+	MinHumidity    float64
+	MaxHumidity    float64
+	MeanHumidity   float64
+	StdDevHumidity float64
+	// PressureCount is the number of temperatures that had a pressure. If it is 0
+	// the other pressure stats are meaningless.
+	PressureCount  int
+	MinPressure    float64
+	MaxPressure    float64
+	MeanPressure   float64
+	StdDevPressure float64
+}
+
+// Status returns the status of the temperatures in the bucket. If any temperature was outside
+// of the range, the bucket is considered outside of it.
+func (ts TemperatureStats) Status(minTemp, maxTemp float64) TemperatureStatus {
+	if status := (Temperature{Value: ts.MaxValue}).Status(minTemp, maxTemp); status == StatusTooHigh {
+		return status
+	}
+	return Temperature{Value: ts.MinValue}.Status(minTemp, maxTemp)
+}
+
+// HumidityStatus returns the status of the humidities in the bucket. If any humidity was outside
+// of the range, the bucket is considered outside of it.
+func (ts TemperatureStats) HumidityStatus(minHumidity, maxHumidity *float64) HumidityStatus {
+	if ts.HumidityCount == 0 {
+		return HumidityNormal
+	}
+	if status := (Temperature{Humidity: &ts.MaxHumidity}).HumidityStatus(minHumidity, maxHumidity); status == HumidityTooHigh {
+		return status
+	}
+	return Temperature{Humidity: &ts.MinHumidity}.HumidityStatus(minHumidity, maxHumidity)
+}
+
+// FindStatsByFridgeID computes statistics for the temperatures of a fridge in the time range [from, to)
+// grouped into buckets of the given size. Buckets are aligned to the unix epoch so a bucket size of
+// a day will start at midnight UTC. Buckets with no temperatures are omitted.
+// If channel is not nil, only the temperatures of the channel are included.
+func (tm *TemperatureManager) FindStatsByFridgeID(ctx context.Context, fridgeID int64, channel *string, from, to time.Time, bucket time.Duration) ([]TemperatureStats, error) {
+	const op = apierror.Op("models.TemperatureManager.FindStatsByFridgeID")
+	bucketSeconds := int64(bucket / time.Second)
+	// Standard deviation isn't built in to SQLite so compute the sum of squares
+	// and use it to calculate the standard deviation after.
+	// Include temperatures that have been rolled up so that stats are available for all time.
+	// Rolled up rows are placed in the bucket their start falls in, so stats for buckets
+	// smaller than the rollup period will be coarser.
+	rows, err := resolveRunner(ctx, tm.db).
+		QueryContext(
+			ctx,
+			`SELECT
+				(CAST(strftime('%s', bucket_start) AS INTEGER) / ?1) * ?1 AS bucket,
+				sum(count), min(value_min), max(value_max), sum(value_sum), sum(value_sum_sq),
+				sum(humidity_count), min(humidity_min), max(humidity_max), total(humidity_sum), total(humidity_sum_sq),
+				sum(pressure_count), min(pressure_min), max(pressure_max), total(pressure_sum), total(pressure_sum_sq)
+			FROM (
+				SELECT
+					created_at AS bucket_start, 1 AS count, value AS value_min, value AS value_max,
+					value AS value_sum, value * value AS value_sum_sq, humidity IS NOT NULL AS humidity_count,
+					humidity AS humidity_min, humidity AS humidity_max, humidity AS humidity_sum,
+					humidity * humidity AS humidity_sum_sq, pressure IS NOT NULL AS pressure_count,
+					pressure AS pressure_min, pressure AS pressure_max, pressure AS pressure_sum,
+					pressure * pressure AS pressure_sum_sq
+				FROM temperatures
+				WHERE fridge_id = ?2 AND (?5 IS NULL OR channel = ?5) AND created_at >= ?3 AND created_at < ?4
+				UNION ALL
+				SELECT `+rollupColumns+` FROM temperatures_hourly
+				WHERE fridge_id = ?2 AND (?5 IS NULL OR channel = ?5) AND bucket_start >= ?3 AND bucket_start < ?4
+				UNION ALL
+				SELECT `+rollupColumns+` FROM temperatures_daily
+				WHERE fridge_id = ?2 AND (?5 IS NULL OR channel = ?5) AND bucket_start >= ?3 AND bucket_start < ?4
+			)
+			GROUP BY bucket
+			ORDER BY bucket`,
+			bucketSeconds,
+			fridgeID,
+			Time{from.UTC()},
+			Time{to.UTC()},
+			channel,
+		)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to compute temperature stats",
+			op,
+		)
+	}
+
+	var stats []TemperatureStats
 	for rows.Next() {
-		var t Temperature
+		var ts TemperatureStats
+		var bucketStart int64
+		var sumValue, sumSqValue, sumHumidity, sumSqHumidity, sumPressure, sumSqPressure float64
+		// NULL if none of the temperatures had a humidity or pressure
+		var minHumidity, maxHumidity, minPressure, maxPressure sql.NullFloat64
 		err := rows.Scan(
-			&t.ID,
-			&t.Value,
-			&t.Humidity,
-			&t.FridgeID,
-			&t.CreatedAt,
+			&bucketStart,
+			&ts.Count,
+			&ts.MinValue,
+			&ts.MaxValue,
+			&sumValue,
+			&sumSqValue,
+			&ts.HumidityCount,
+			&minHumidity,
+			&maxHumidity,
+			&sumHumidity,
+			&sumSqHumidity,
+			&ts.PressureCount,
+			&minPressure,
+			&maxPressure,
+			&sumPressure,
+			&sumSqPressure,
+		)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan temperature stats row",
+				op,
+			)
+		}
+		ts.BucketStart = time.Unix(bucketStart, 0).UTC()
+		ts.MeanValue, ts.StdDevValue = meanStdDev(ts.Count, sumValue, sumSqValue)
+		ts.MinHumidity, ts.MaxHumidity = minHumidity.Float64, maxHumidity.Float64
+		ts.MeanHumidity, ts.StdDevHumidity = meanStdDev(ts.HumidityCount, sumHumidity, sumSqHumidity)
+		ts.MinPressure, ts.MaxPressure = minPressure.Float64, maxPressure.Float64
+		ts.MeanPressure, ts.StdDevPressure = meanStdDev(ts.PressureCount, sumPressure, sumSqPressure)
+		stats = append(stats, ts)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over temperature stats rows",
+			op,
+		)
+	}
+	return stats, nil
+}
+
+const rollupColumns = `bucket_start, count, value_min, value_max, value_sum, value_sum_sq,
+	humidity_count, humidity_min, humidity_max, humidity_sum, humidity_sum_sq,
+	pressure_count, pressure_min, pressure_max, pressure_sum, pressure_sum_sq`
+
+// RollupHourly aggregates all temperatures created before the given time into hourly buckets
+// for each channel and deletes them. before should be at the start of an hour so that only complete hours are rolled up.
+// It returns the number of temperatures that were rolled up.
+func (tm *TemperatureManager) RollupHourly(ctx context.Context, before time.Time) (int64, error) {
+	const op = apierror.Op("models.TemperatureManager.RollupHourly")
+	return tm.rollup(
+		ctx,
+		op,
+		`INSERT INTO temperatures_hourly(fridge_id, channel, `+rollupColumns+`)
+			SELECT
+				fridge_id, channel, strftime('%Y-%m-%d %H:00:00', created_at), count(*), min(value), max(value),
+				sum(value), sum(value * value), count(humidity), min(humidity), max(humidity), total(humidity), total(humidity * humidity),
+				count(pressure), min(pressure), max(pressure), total(pressure), total(pressure * pressure)
+			FROM temperatures
+			WHERE created_at < ?
+			GROUP BY 1, 2, 3`,
+		`DELETE FROM temperatures WHERE created_at < ?`,
+		before,
+	)
+}
+
+// RollupDaily aggregates all hourly rollups before the given time into daily buckets
+// for each channel and deletes them. before should be at the start of a day so that only complete days are rolled up.
+// It returns the number of hourly rollups that were rolled up.
+func (tm *TemperatureManager) RollupDaily(ctx context.Context, before time.Time) (int64, error) {
+	const op = apierror.Op("models.TemperatureManager.RollupDaily")
+	return tm.rollup(
+		ctx,
+		op,
+		`INSERT INTO temperatures_daily(fridge_id, channel, `+rollupColumns+`)
+			SELECT
+				fridge_id, channel, strftime('%Y-%m-%d 00:00:00', bucket_start), sum(count), min(value_min), max(value_max),
+				sum(value_sum), sum(value_sum_sq), sum(humidity_count), min(humidity_min), max(humidity_max), sum(humidity_sum), sum(humidity_sum_sq),
+				sum(pressure_count), min(pressure_min), max(pressure_max), sum(pressure_sum), sum(pressure_sum_sq)
+			FROM temperatures_hourly
+			WHERE bucket_start < ?
+			GROUP BY 1, 2, 3`,
+		`DELETE FROM temperatures_hourly WHERE bucket_start < ?`,
+		before,
+	)
+}
+
+// rollup runs insertQuery to aggregate rows into buckets, then deleteQuery to remove the aggregated rows.
+// Both queries take before as their only parameter.
+func (tm *TemperatureManager) rollup(ctx context.Context, op apierror.Op, insertQuery, deleteQuery string, before time.Time) (int64, error) {
+	txn := requireTxn(ctx)
+	// A bucket may already exist if temperatures were received late, ex: from a sensor uploading old readings.
+	// In that case merge the new rows into the existing bucket.
+	// The humidity and pressure min and max are NULL if there were no values and min and max return NULL
+	// if either argument is, so coalesce them to keep the other one.
+	upsert := insertQuery + `
+		ON CONFLICT(fridge_id, channel, bucket_start) DO UPDATE SET
+			count = count + excluded.count,
+			value_min = min(value_min, excluded.value_min),
+			value_max = max(value_max, excluded.value_max),
+			value_sum = value_sum + excluded.value_sum,
+			value_sum_sq = value_sum_sq + excluded.value_sum_sq,
+			humidity_count = humidity_count + excluded.humidity_count,
+			humidity_min = min(coalesce(humidity_min, excluded.humidity_min), coalesce(excluded.humidity_min, humidity_min)),
+			humidity_max = max(coalesce(humidity_max, excluded.humidity_max), coalesce(excluded.humidity_max, humidity_max)),
+			humidity_sum = humidity_sum + excluded.humidity_sum,
+			humidity_sum_sq = humidity_sum_sq + excluded.humidity_sum_sq,
+			pressure_count = pressure_count + excluded.pressure_count,
+			pressure_min = min(coalesce(pressure_min, excluded.pressure_min), coalesce(excluded.pressure_min, pressure_min)),
+			pressure_max = max(coalesce(pressure_max, excluded.pressure_max), coalesce(excluded.pressure_max, pressure_max)),
+			pressure_sum = pressure_sum + excluded.pressure_sum,
+			pressure_sum_sq = pressure_sum_sq + excluded.pressure_sum_sq`
+	if _, err := txn.ExecContext(ctx, upsert, Time{before.UTC()}); err != nil {
+		return 0, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to insert rollup rows",
+			op,
+		)
+	}
+	result, err := txn.ExecContext(ctx, deleteQuery, Time{before.UTC()})
+	if err != nil {
+		return 0, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to delete rolled up rows",
+			op,
+		)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to get number of rolled up rows",
+			op,
 		)
+	}
+	return n, nil
+}
+
+// meanStdDev computes the mean and population standard deviation from the count, sum, and sum of squares of some values.
+func meanStdDev(count int, sum, sumSq float64) (mean, stdDev float64) {
+	if count == 0 {
+		return 0, 0
+	}
+	n := float64(count)
+	mean = sum / n
+	// Floating point error can make the variance slightly negative when all values are the same
+	variance := math.Max(sumSq/n-mean*mean, 0)
+	return mean, math.Sqrt(variance)
+}
+
+func (tm *TemperatureManager) findAll(ctx context.Context, op apierror.Op, query string, args ...any) ([]Temperature, error) {
+	rows, err := resolveRunner(ctx, tm.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve temperatures",
+			op,
+		)
+	}
+
+	var temperatures []Temperature
+	for rows.Next() {
+		t, err := scanTemperature(rows)
 		if err != nil {
 			return nil, apierror.Wrap(
 				err,
@@ -104,24 +606,68 @@ func (tm *TemperatureManager) FindMostRecentByFridgeID(ctx context.Context, frid
 	return temperatures, nil
 }
 
-func (tm *TemperatureManager) InsertOne(ctx context.Context, fridgeID int64, value, humidity float64) (Temperature, error) {
+// InsertMany inserts all the temperatures. If the CreatedAt field of a temperature is zero,
+// the current time is used. The ID field is ignored. The inserted temperatures are returned
+// in the same order as temps.
+func (tm *TemperatureManager) InsertMany(ctx context.Context, temps []Temperature) ([]Temperature, error) {
+	const op = apierror.Op("models.TemperatureManager.InsertMany")
+	stmt, err := requireTxn(ctx).PrepareContext(
+		ctx,
+		`INSERT INTO temperatures(value, humidity, pressure, fridge_id, channel, device_id, created_at)
+			VALUES(?, ?, ?, ?, ?, ?, COALESCE(?, datetime('now')))
+			RETURNING `+temperatureColumns,
+	)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to prepare temperature insert",
+			op,
+		)
+	}
+	defer stmt.Close()
+
+	inserted := make([]Temperature, len(temps))
+	for i, t := range temps {
+		createdAt := NullTime{Time: Time{t.CreatedAt.UTC()}, Valid: !t.CreatedAt.IsZero()}
+		newTemp, err := scanTemperature(stmt.QueryRowContext(
+			ctx,
+			t.Value,
+			t.Humidity,
+			t.Pressure,
+			t.FridgeID,
+			t.Channel,
+			t.DeviceID,
+			createdAt,
+		))
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to insert temperature row",
+				op,
+			)
+		}
+		inserted[i] = newTemp
+	}
+	return inserted, nil
+}
+
+// InsertOne inserts a new temperature. The ID and CreatedAt fields of temp are ignored.
+func (tm *TemperatureManager) InsertOne(ctx context.Context, temp Temperature) (Temperature, error) {
 	const op = apierror.Op("models.TemperatureManager.InsertOne")
-	var newTemp Temperature
-	err := requireTxn(ctx).
+	row := requireTxn(ctx).
 		QueryRowContext(
 			ctx,
-			`INSERT INTO temperatures(value, humidity, fridge_id) VALUES(?, ?, ?) RETURNING id, value, humidity, fridge_id, created_at`,
-			value,
-			humidity,
-			fridgeID,
-		).
-		Scan(
-			&newTemp.ID,
-			&newTemp.Value,
-			&newTemp.Humidity,
-			&newTemp.FridgeID,
-			&newTemp.CreatedAt,
+			`INSERT INTO temperatures(value, humidity, pressure, fridge_id, channel, device_id) VALUES(?, ?, ?, ?, ?, ?) RETURNING `+temperatureColumns,
+			temp.Value,
+			temp.Humidity,
+			temp.Pressure,
+			temp.FridgeID,
+			temp.Channel,
+			temp.DeviceID,
 		)
+	newTemp, err := scanTemperature(row)
 	if err != nil {
 		return newTemp, apierror.Wrap(
 			err,
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+)
+
+// SensorToken is an API token that allows a sensor to send temperatures for a single fridge.
+type SensorToken struct {
+	ID        int64
+	FridgeID  int64
+	Name      string
+	CreatedAt Time
+}
+
+const sensorTokenColumns = `id, fridge_id, name, created_at`
+
+func scanSensorToken(row rowScanner) (SensorToken, error) {
+	var st SensorToken
+	err := row.Scan(
+		&st.ID,
+		&st.FridgeID,
+		&st.Name,
+		&st.CreatedAt,
+	)
+	return st, err
+}
+
+// hashToken returns the hash of token that is stored in the database.
+// Tokens are random and high entropy so a fast hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type SensorTokenManager struct {
+	db *sql.DB
+}
+
+func NewSensorTokenManager(db *sql.DB) *SensorTokenManager {
+	return &SensorTokenManager{db}
+}
+
+// FindOneByToken finds the sensor token that matches the given plaintext token.
+func (stm *SensorTokenManager) FindOneByToken(ctx context.Context, token string) (SensorToken, error) {
+	const op = apierror.Op("models.SensorTokenManager.FindOneByToken")
+	row := resolveRunner(ctx, stm.db).
+		QueryRowContext(ctx, `SELECT `+sensorTokenColumns+` FROM sensor_tokens WHERE token_hash = ?`, hashToken(token))
+	st, err := scanSensorToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, apierror.New(
+			apierror.CodeRecordNotFound,
+			"no sensor token found",
+			op,
+		)
+	} else if err != nil {
+		return st, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve sensor token",
+			op,
+		)
+	}
+	return st, nil
+}
+
+func (stm *SensorTokenManager) FindAllByFridgeID(ctx context.Context, fridgeID int64) ([]SensorToken, error) {
+	const op = apierror.Op("models.SensorTokenManager.FindAllByFridgeID")
+	rows, err := resolveRunner(ctx, stm.db).
+		QueryContext(ctx, `SELECT `+sensorTokenColumns+` FROM sensor_tokens WHERE fridge_id = ? ORDER BY id`, fridgeID)
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to retrieve sensor tokens",
+			op,
+		)
+	}
+
+	var tokens []SensorToken
+	for rows.Next() {
+		st, err := scanSensorToken(rows)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeDatabase,
+				"failed to scan sensor token row",
+				op,
+			)
+		}
+		tokens = append(tokens, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"error occurred while iterating over sensor token rows",
+			op,
+		)
+	}
+	return tokens, nil
+}
+
+// InsertOne creates a new sensor token for the fridge. The plaintext token is returned
+// along with the created SensorToken. It is not stored and cannot be retrieved again.
+func (stm *SensorTokenManager) InsertOne(ctx context.Context, fridgeID int64, name string) (SensorToken, string, error) {
+	const op = apierror.Op("models.SensorTokenManager.InsertOne")
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return SensorToken{}, "", apierror.Wrap(
+			err,
+			apierror.CodeUnknown,
+			"failed to generate sensor token",
+			op,
+		)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	row := requireTxn(ctx).
+		QueryRowContext(
+			ctx,
+			`INSERT INTO sensor_tokens(fridge_id, name, token_hash) VALUES(?, ?, ?) RETURNING `+sensorTokenColumns,
+			fridgeID,
+			name,
+			hashToken(token),
+		)
+	st, err := scanSensorToken(row)
+	if err != nil {
+		return st, "", apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to insert sensor token row",
+			op,
+		)
+	}
+	return st, token, nil
+}
+
+func (stm *SensorTokenManager) DeleteOne(ctx context.Context, fridgeID, id int64) error {
+	const op = apierror.Op("models.SensorTokenManager.DeleteOne")
+	result, err := requireTxn(ctx).
+		ExecContext(ctx, `DELETE FROM sensor_tokens WHERE fridge_id = ? AND id = ?`, fridgeID, id)
+	if err != nil {
+		return apierror.Wrap(
+			err,
+			apierror.CodeDatabase,
+			"failed to delete sensor token row",
+			op,
+		)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return apierror.New(
+			apierror.CodeRecordNotFound,
+			fmt.Sprintf("no sensor token found with id %d for fridge %d", id, fridgeID),
+			op,
+		)
+	}
+	return nil
+}
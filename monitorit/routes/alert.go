@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AlertHandler struct {
+	am  *models.AlertManager
+	hub *events.Hub
+}
+
+func NewAlertHandler(am *models.AlertManager, hub *events.Hub) *AlertHandler {
+	return &AlertHandler{am, hub}
+}
+
+type alertResponse struct {
+	ID                string  `json:"id"`
+	FridgeID          string  `json:"fridgeId"`
+	Kind              string  `json:"kind"`
+	Channel           string  `json:"channel"`
+	State             string  `json:"state"`
+	Message           string  `json:"message"`
+	NotificationCount int     `json:"notificationCount"`
+	CreatedAt         string  `json:"createdAt"`
+	LastNotifiedAt    string  `json:"lastNotifiedAt"`
+	AcknowledgedAt    *string `json:"acknowledgedAt"`
+	ResolvedAt        *string `json:"resolvedAt"`
+}
+
+func newAlertResponse(a models.Alert) alertResponse {
+	resp := alertResponse{
+		ID:                strconv.FormatInt(a.ID, 10),
+		FridgeID:          strconv.FormatInt(a.FridgeID, 10),
+		Kind:              string(a.Kind),
+		Channel:           a.Channel,
+		State:             string(a.State),
+		Message:           a.Message,
+		NotificationCount: a.NotificationCount,
+		CreatedAt:         a.CreatedAt.Format(time.RFC3339),
+		LastNotifiedAt:    a.LastNotifiedAt.Format(time.RFC3339),
+	}
+	if a.AcknowledgedAt.Valid {
+		s := a.AcknowledgedAt.Time.Format(time.RFC3339)
+		resp.AcknowledgedAt = &s
+	}
+	if a.ResolvedAt.Valid {
+		s := a.ResolvedAt.Time.Format(time.RFC3339)
+		resp.ResolvedAt = &s
+	}
+	return resp
+}
+
+// List returns all alerts that are not resolved.
+func (ah *AlertHandler) List(ctx context.Context, c *fiber.Ctx) (any, error) {
+	alerts, err := ah.am.FindAllUnresolved(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		Alerts []alertResponse `json:"alerts"`
+	}{Alerts: make([]alertResponse, len(alerts))}
+	for i, a := range alerts {
+		body.Alerts[i] = newAlertResponse(a)
+	}
+	return body, nil
+}
+
+func (ah *AlertHandler) Get(ctx context.Context, c *fiber.Ctx) (any, error) {
+	id, err := paramInt64(c, "alertID")
+	if err != nil {
+		return nil, err
+	}
+	a, err := ah.am.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return newAlertResponse(a), nil
+}
+
+// Acknowledge acknowledges the alert which stops reminders from being sent for it.
+func (ah *AlertHandler) Acknowledge(ctx context.Context, c *fiber.Ctx) (any, error) {
+	id, err := paramInt64(c, "alertID")
+	if err != nil {
+		return nil, err
+	}
+	a, err := ah.am.Acknowledge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	afterCommit(ctx, func() {
+		ah.hub.Publish(events.Event{Type: events.TypeAlert, FridgeID: a.FridgeID, Data: a})
+	})
+	if isHTML(c) {
+		return redirect(fmt.Sprintf("/fridges/%d", a.FridgeID)), nil
+	}
+	return newAlertResponse(a), nil
+}
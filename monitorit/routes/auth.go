@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminCredentials are the credentials required to access admin routes using HTTP basic auth.
+type AdminCredentials struct {
+	Username string
+	Password string
+}
+
+// valid checks if the given username and password match the credentials in constant time.
+func (ac AdminCredentials) valid(username, password string) bool {
+	// Compare hashes so the comparison doesn't leak the length of the credentials
+	wantUser := sha256.Sum256([]byte(ac.Username))
+	wantPass := sha256.Sum256([]byte(ac.Password))
+	gotUser := sha256.Sum256([]byte(username))
+	gotPass := sha256.Sum256([]byte(password))
+	userMatch := subtle.ConstantTimeCompare(wantUser[:], gotUser[:])
+	passMatch := subtle.ConstantTimeCompare(wantPass[:], gotPass[:])
+	return userMatch&passMatch == 1
+}
+
+// requireAdmin creates a middleware that only allows requests with valid admin credentials.
+func requireAdmin(creds AdminCredentials) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		const op = apierror.Op("routes.requireAdmin")
+		username, password, ok := basicAuth(c)
+		if !ok {
+			// Prompt browsers for credentials
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="MonitorIt", charset="UTF-8"`)
+			return apierror.New(apierror.CodeUnauthorized, "admin credentials are required", op)
+		}
+		if !creds.valid(username, password) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="MonitorIt", charset="UTF-8"`)
+			return apierror.New(apierror.CodeUnauthorized, "invalid admin credentials", op)
+		}
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead && !sameOrigin(c) {
+			return apierror.New(apierror.CodeForbidden, "requests from other sites are not allowed", op)
+		}
+		return c.Next()
+	}
+}
+
+// requireSensorToken creates a middleware that only allows requests with a sensor token
+// for the fridge in the fridgeID route param. Admin credentials are also accepted.
+func requireSensorToken(stm *models.SensorTokenManager, creds AdminCredentials) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		const op = apierror.Op("routes.requireSensorToken")
+		if username, password, ok := basicAuth(c); ok && creds.valid(username, password) {
+			// Browsers send the stored admin credentials with forms on other sites too, see requireAdmin
+			if !sameOrigin(c) {
+				return apierror.New(apierror.CodeForbidden, "requests from other sites are not allowed", op)
+			}
+			return c.Next()
+		}
+
+		auth := c.Get(fiber.HeaderAuthorization)
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if auth == "" || token == auth {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="MonitorIt"`)
+			return apierror.New(apierror.CodeUnauthorized, "a sensor token is required", op)
+		}
+		st, err := stm.FindOneByToken(c.Context(), token)
+		var apiErr apierror.Error
+		if errors.As(err, &apiErr) && apiErr.Code() == apierror.CodeRecordNotFound {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="MonitorIt", error="invalid_token"`)
+			return apierror.New(apierror.CodeUnauthorized, "invalid sensor token", op)
+		} else if err != nil {
+			return err
+		}
+
+		fridgeID, err := paramInt64(c, "fridgeID")
+		if err != nil {
+			return err
+		}
+		if st.FridgeID != fridgeID {
+			return apierror.New(apierror.CodeForbidden, "sensor token is not allowed to access this fridge", op)
+		}
+		return c.Next()
+	}
+}
+
+// basicAuth returns the username and password from the Authorization header if it uses HTTP basic auth.
+func basicAuth(c *fiber.Ctx) (username, password string, ok bool) {
+	auth := c.Get(fiber.HeaderAuthorization)
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	username, password, ok = strings.Cut(string(decoded), ":")
+	return username, password, ok
+}
@@ -0,0 +1,248 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ContactHandler struct {
+	fm *models.FridgeManager
+	cm *models.ContactManager
+}
+
+func NewContactHandler(fm *models.FridgeManager, cm *models.ContactManager) *ContactHandler {
+	return &ContactHandler{fm, cm}
+}
+
+type contactResponse struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Address         string `json:"address"`
+	EscalationLevel int    `json:"escalationLevel"`
+}
+
+func newContactResponse(fc models.FridgeContact) contactResponse {
+	return contactResponse{
+		ID:              strconv.FormatInt(fc.ID, 10),
+		Name:            fc.Name,
+		Address:         fc.Address,
+		EscalationLevel: fc.EscalationLevel,
+	}
+}
+
+func (ch *ContactHandler) List(ctx context.Context, c *fiber.Ctx) (any, error) {
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the fridge exists so a 404 is returned instead of an empty list
+	if _, err := ch.fm.FindOneByID(ctx, fridgeID); err != nil {
+		return nil, err
+	}
+	contacts, err := ch.cm.FindAllByFridgeID(ctx, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		Contacts []contactResponse `json:"contacts"`
+	}{Contacts: make([]contactResponse, len(contacts))}
+	for i, fc := range contacts {
+		body.Contacts[i] = newContactResponse(fc)
+	}
+	return body, nil
+}
+
+func (ch *ContactHandler) Get(ctx context.Context, c *fiber.Ctx) (any, error) {
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	contactID, err := paramInt64(c, "contactID")
+	if err != nil {
+		return nil, err
+	}
+	fc, err := ch.cm.FindOneByFridgeID(ctx, fridgeID, contactID)
+	if err != nil {
+		return nil, err
+	}
+	return newContactResponse(fc), nil
+}
+
+// Create adds a contact to a fridge. If contactId is provided in the body, the existing contact
+// is added to the fridge, otherwise a new contact is created using name and address.
+func (ch *ContactHandler) Create(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.ContactHandler.Create")
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	var reqBody struct {
+		ContactID       string `json:"contactId"`
+		Name            string `json:"name"`
+		Address         string `json:"address"`
+		EscalationLevel int    `json:"escalationLevel"`
+	}
+	if err := parseBody(c, &reqBody); err != nil {
+		return nil, err
+	}
+	var v validator
+	var contactID int64
+	if reqBody.ContactID != "" {
+		contactID, err = strconv.ParseInt(reqBody.ContactID, 10, 64)
+		v.check(err == nil, "contactId", "must be the ID of a contact, got %q", reqBody.ContactID)
+	} else {
+		// Only needed when creating a new contact
+		v.checkName(reqBody.Name, "name")
+		checkAddress(&v, reqBody.Address)
+	}
+	v.check(reqBody.EscalationLevel >= 0, "escalationLevel", "must not be negative")
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+	if _, err := ch.fm.FindOneByID(ctx, fridgeID); err != nil {
+		return nil, err
+	}
+
+	var contact models.Contact
+	if reqBody.ContactID != "" {
+		contact, err = ch.cm.FindOneByID(ctx, contactID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		contact, err = ch.cm.InsertOne(ctx, models.Contact{
+			Name:    reqBody.Name,
+			Address: reqBody.Address,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fc, err := ch.cm.AddToFridge(ctx, fridgeID, contact.ID, reqBody.EscalationLevel)
+	if err != nil {
+		return nil, err
+	}
+	return newContactResponse(fc), nil
+}
+
+func (ch *ContactHandler) Update(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.ContactHandler.Update")
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	contactID, err := paramInt64(c, "contactID")
+	if err != nil {
+		return nil, err
+	}
+	var reqBody struct {
+		Name            string `json:"name"`
+		Address         string `json:"address"`
+		EscalationLevel *int   `json:"escalationLevel"`
+	}
+	if err := parseBody(c, &reqBody); err != nil {
+		return nil, err
+	}
+	// Empty fields aren't updated
+	var v validator
+	if reqBody.Name != "" {
+		v.checkName(reqBody.Name, "name")
+	}
+	if reqBody.Address != "" {
+		checkAddress(&v, reqBody.Address)
+	}
+	if reqBody.EscalationLevel != nil {
+		v.check(*reqBody.EscalationLevel >= 0, "escalationLevel", "must not be negative")
+	}
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+
+	// Make sure the contact belongs to the fridge before changing anything
+	if _, err := ch.cm.FindOneByFridgeID(ctx, fridgeID, contactID); err != nil {
+		return nil, err
+	}
+	// The name and address are shared by every fridge the contact belongs to, don't change them
+	// for the other fridges without the admin realizing it
+	if reqBody.Name != "" || reqBody.Address != "" {
+		count, err := ch.cm.CountFridges(ctx, contactID)
+		if err != nil {
+			return nil, err
+		}
+		if count > 1 {
+			return nil, apierror.New(
+				apierror.CodeConflict,
+				fmt.Sprintf(
+					"contact %d is a contact of %d fridges, changing its name or address would change it for all of them. Add a new contact to this fridge instead",
+					contactID,
+					count,
+				),
+				op,
+			)
+		}
+	}
+	err = ch.cm.UpdateOne(ctx, contactID, models.PartialContact{
+		Name:    reqBody.Name,
+		Address: reqBody.Address,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reqBody.EscalationLevel != nil {
+		if err := ch.cm.UpdateEscalationLevel(ctx, fridgeID, contactID, *reqBody.EscalationLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	fc, err := ch.cm.FindOneByFridgeID(ctx, fridgeID, contactID)
+	if err != nil {
+		return nil, err
+	}
+	return newContactResponse(fc), nil
+}
+
+func (ch *ContactHandler) Delete(ctx context.Context, c *fiber.Ctx) (any, error) {
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	contactID, err := paramInt64(c, "contactID")
+	if err != nil {
+		return nil, err
+	}
+	fc, err := ch.cm.FindOneByFridgeID(ctx, fridgeID, contactID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.cm.RemoveFromFridge(ctx, fridgeID, contactID); err != nil {
+		return nil, err
+	}
+	return newContactResponse(fc), nil
+}
+
+// maxAddressLen is the max length of the address of a contact, the max length of an email address.
+const maxAddressLen = 254
+
+// checkAddress checks that address can be used to send notifications. It can be an email address
+// or a phone number depending on the notifier, so only email addresses are fully validated.
+func checkAddress(v *validator, address string) {
+	v.check(strings.TrimSpace(address) != "", "address", "must not be empty")
+	v.check(len(address) <= maxAddressLen, "address", "must be at most %d characters", maxAddressLen)
+	// Control characters could be used to add headers to emails, ex: a CRLF followed by Bcc
+	if strings.IndexFunc(address, unicode.IsControl) >= 0 {
+		v.check(false, "address", "must not contain control characters")
+	} else if strings.Contains(address, "@") {
+		// Only allow a bare address since the name of the contact is stored separately
+		addr, err := mail.ParseAddress(address)
+		v.check(err == nil && addr.Address == address, "address", "must be a valid email address")
+	}
+}
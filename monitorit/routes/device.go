@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeviceHandler struct {
+	fm *models.FridgeManager
+	tm *models.TemperatureManager
+	dm *models.DeviceManager
+}
+
+func NewDeviceHandler(fm *models.FridgeManager, tm *models.TemperatureManager, dm *models.DeviceManager) *DeviceHandler {
+	return &DeviceHandler{fm, tm, dm}
+}
+
+type deviceResponse struct {
+	ID         string  `json:"id"`
+	Serial     string  `json:"serial"`
+	Firmware   string  `json:"firmware"`
+	FridgeID   *string `json:"fridgeId"`
+	CreatedAt  string  `json:"createdAt"`
+	LastSeenAt string  `json:"lastSeenAt"`
+	// Silent is true if the device hasn't sent a temperature within the max silence
+	// of its fridge. It most likely means the device is dead or disconnected.
+	Silent bool `json:"silent"`
+	// Only used by the view
+	FridgeName string `json:"-"`
+}
+
+// newDeviceResponse creates a response for the device. fridge is the fridge the device
+// is assigned to, or nil if the device is not assigned to a fridge.
+func newDeviceResponse(d models.Device, fridge *models.Fridge) deviceResponse {
+	resp := deviceResponse{
+		ID:         strconv.FormatInt(d.ID, 10),
+		Serial:     d.Serial,
+		Firmware:   d.Firmware,
+		CreatedAt:  d.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: d.LastSeenAt.Format(time.RFC3339),
+	}
+	maxSilence := models.DefaultMaxSilence
+	if fridge != nil {
+		fridgeID := strconv.FormatInt(fridge.ID, 10)
+		resp.FridgeID = &fridgeID
+		resp.FridgeName = fridge.Name
+		maxSilence = fridge.MaxSilence
+	}
+	resp.Silent = time.Since(d.LastSeenAt.Time) >= maxSilence
+	return resp
+}
+
+func (dh *DeviceHandler) List(ctx context.Context, c *fiber.Ctx) (any, error) {
+	devices, err := dh.dm.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fridges, err := dh.fm.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Devices may still be assigned to fridges that were archived
+	archived, err := dh.fm.FindAllArchived(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fridges = append(fridges, archived...)
+	fridgesByID := make(map[int64]*models.Fridge, len(fridges))
+	for i := range fridges {
+		fridgesByID[fridges[i].ID] = &fridges[i]
+	}
+
+	body := struct {
+		Devices []deviceResponse `json:"devices"`
+	}{Devices: make([]deviceResponse, len(devices))}
+	for i, d := range devices {
+		var fridge *models.Fridge
+		if d.FridgeID != nil {
+			fridge = fridgesByID[*d.FridgeID]
+		}
+		body.Devices[i] = newDeviceResponse(d, fridge)
+		if isHTML(c) {
+			body.Devices[i].LastSeenAt = d.LastSeenAt.Local().Format(models.TimeFormatPretty)
+		}
+	}
+	return body, nil
+}
+
+func (dh *DeviceHandler) Get(ctx context.Context, c *fiber.Ctx) (any, error) {
+	id, err := paramInt64(c, "deviceID")
+	if err != nil {
+		return nil, err
+	}
+	d, err := dh.dm.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var fridge *models.Fridge
+	if d.FridgeID != nil {
+		f, err := dh.fm.FindOneByID(ctx, *d.FridgeID)
+		if err != nil {
+			return nil, err
+		}
+		fridge = &f
+	}
+
+	body := struct {
+		deviceResponse
+		Temperatures []temperatureResponse `json:"temperatures,omitempty"`
+	}{
+		deviceResponse: newDeviceResponse(d, fridge),
+	}
+	if isHTML(c) {
+		body.LastSeenAt = d.LastSeenAt.Local().Format(models.TimeFormatPretty)
+
+		// If html then also include the last 5 temperatures sent by the device
+		temperatures, err := dh.tm.FindMostRecentByDeviceID(ctx, d.ID, 5)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range temperatures {
+			body.Temperatures = append(body.Temperatures, temperatureResponse{
+				ID:        strconv.FormatInt(t.ID, 10),
+				Value:     t.Value,
+				Humidity:  t.Humidity,
+				Pressure:  t.Pressure,
+				CreatedAt: t.CreatedAt.Local().Format(models.TimeFormatPretty),
+			})
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// EventHandler streams changes to fridges to clients using server-sent events so pages can update live.
+type EventHandler struct {
+	fm  *models.FridgeManager
+	hub *events.Hub
+}
+
+func NewEventHandler(fm *models.FridgeManager, hub *events.Hub) *EventHandler {
+	return &EventHandler{fm, hub}
+}
+
+const (
+	// keepAliveInterval is how often a comment is sent if there are no events. It stops proxies from
+	// closing the connection and makes sure disconnected clients are noticed.
+	keepAliveInterval = 30 * time.Second
+	// reconnectDelay is how long clients wait before reconnecting if the connection is lost.
+	reconnectDelay = 5 * time.Second
+)
+
+// Stream sends the events for all fridges, or only for the fridge in the fridgeID route param if it is set.
+func (eh *EventHandler) Stream(c *fiber.Ctx) error {
+	var fridgeID int64
+	if c.Params("fridgeID") != "" {
+		id, err := paramInt64(c, "fridgeID")
+		if err != nil {
+			return err
+		}
+		// Make sure the fridge exists, otherwise the client would wait forever for events that will never come
+		if _, err := eh.fm.FindOneByID(c.Context(), id); err != nil {
+			return err
+		}
+		fridgeID = id
+	}
+
+	eventCh, unsubscribe := eh.hub.Subscribe(fridgeID)
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Stop nginx from buffering the events
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		// Send something right away so the client knows the stream is open
+		fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+		if err := w.Flush(); err != nil {
+			return
+		}
+		ticker := time.NewTicker(keepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case e, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, e); err != nil {
+					log.Printf("Error: failed to write %s event: %v", e.Type, err)
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			// Flush fails once the client has disconnected
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// writeEvent writes the event in the server-sent events format with its data as JSON.
+// The data is the same as the responses of the other routes so clients can handle them the same way.
+func writeEvent(w *bufio.Writer, e events.Event) error {
+	var data any
+	switch d := e.Data.(type) {
+	case models.Temperature:
+		data = struct {
+			FridgeID string `json:"fridgeId"`
+			temperatureResponse
+		}{strconv.FormatInt(d.FridgeID, 10), newTemperatureResponse(d)}
+	case models.Alert:
+		data = newAlertResponse(d)
+	default:
+		data = d
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, b)
+	return err
+}
@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportFlushInterval is how many rows are written before flushing the response.
+const exportFlushInterval = 500
+
+// ExportTemperatures streams all temperatures of a fridge in a time range as either CSV or NDJSON.
+// Temperatures are written as they are read from the database so the whole history is never held in memory.
+// Temperatures that have been rolled up by the retention job are not included.
+func (fh *FridgeHandler) ExportTemperatures(c *fiber.Ctx) error {
+	const op = apierror.Op("routes.FridgeHandler.ExportTemperatures")
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return err
+	}
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return err
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return err
+	}
+	format := c.Query("format", "csv")
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	case "ndjson":
+		contentType = "application/x-ndjson"
+	default:
+		return apierror.New(
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("format must be csv or ndjson, got %q", format),
+			op,
+		)
+	}
+	fridge, err := fh.fm.FindOneByID(c.Context(), fridgeID)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-temperatures.%s"`, exportFilename(fridge.Name), format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The request context can't be used since the stream is written after the handler returns
+		ctx := context.Background()
+		var err error
+		switch format {
+		case "csv":
+			err = fh.exportCSV(ctx, w, fridge, from, to)
+		case "ndjson":
+			err = fh.exportNDJSON(ctx, w, fridge, from, to)
+		}
+		if err != nil {
+			// The status has already been sent so all we can do is log it, the client
+			// will see a truncated response
+			log.Printf("Error: failed to export temperatures for fridge %d: %v", fridge.ID, err)
+		}
+	})
+	return nil
+}
+
+func (fh *FridgeHandler) exportCSV(ctx context.Context, w *bufio.Writer, fridge models.Fridge, from, to time.Time) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"fridge", "channel", "created_at", "value", "humidity", "pressure"}); err != nil {
+		return err
+	}
+	n := 0
+	err := fh.tm.EachByFridgeID(ctx, fridge.ID, from, to, func(t models.Temperature) error {
+		err := cw.Write([]string{
+			fridge.Name,
+			t.Channel,
+			t.CreatedAt.Format(time.RFC3339),
+			strconv.FormatFloat(t.Value, 'f', -1, 64),
+			formatOptionalFloat(t.Humidity),
+			formatOptionalFloat(t.Pressure),
+		})
+		if err != nil {
+			return err
+		}
+		n++
+		if n%exportFlushInterval == 0 {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (fh *FridgeHandler) exportNDJSON(ctx context.Context, w *bufio.Writer, fridge models.Fridge, from, to time.Time) error {
+	enc := json.NewEncoder(w)
+	n := 0
+	return fh.tm.EachByFridgeID(ctx, fridge.ID, from, to, func(t models.Temperature) error {
+		row := struct {
+			Fridge    string   `json:"fridge"`
+			Channel   string   `json:"channel"`
+			CreatedAt string   `json:"createdAt"`
+			Value     float64  `json:"value"`
+			Humidity  *float64 `json:"humidity"`
+			Pressure  *float64 `json:"pressure"`
+		}{
+			Fridge:    fridge.Name,
+			Channel:   t.Channel,
+			CreatedAt: t.CreatedAt.Format(time.RFC3339),
+			Value:     t.Value,
+			Humidity:  t.Humidity,
+			Pressure:  t.Pressure,
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+		n++
+		if n%exportFlushInterval == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// exportFilename converts the fridge name into something that is safe to use as a filename.
+func exportFilename(name string) string {
+	s := unsafeFilenameChars.ReplaceAllString(name, "-")
+	if s == "" || s == "-" {
+		return "fridge"
+	}
+	return s
+}
+
+// formatOptionalFloat formats f for a CSV cell. If f is nil, the cell is empty.
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
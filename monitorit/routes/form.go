@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/gofiber/fiber/v2"
+)
+
+// redirect can be returned by a handler to redirect the client to the path instead of responding with data.
+// It should only be returned for HTML requests, ex: to show the fridge after it was created with a form.
+type redirect string
+
+// form is the data used to render an HTML form. Values and Errors are keyed by the name of the input.
+type form struct {
+	// Action is the path the form is submitted to.
+	Action string
+	Values map[string]string
+	// Errors are the problems with the value of each input.
+	Errors map[string]string
+	// Message is a problem that isn't about a single input, ex: a fridge with the name already exists.
+	Message string
+}
+
+// isForm returns whether the request body was submitted by an HTML form.
+func isForm(c *fiber.Ctx) bool {
+	return strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationForm)
+}
+
+// formFromError creates a form to show the user what was wrong with the values they submitted.
+// It returns false if err isn't caused by the submitted values.
+func formFromError(c *fiber.Ctx, err error) (form, bool) {
+	var apiErr apierror.Error
+	if !errors.As(err, &apiErr) {
+		return form{}, false
+	}
+	switch apiErr.Code() {
+	case apierror.CodeValidation, apierror.CodeInvalidParameter, apierror.CodeConflict:
+	default:
+		return form{}, false
+	}
+
+	f := form{
+		Action: c.Path(),
+		Values: make(map[string]string),
+		Errors: make(map[string]string),
+	}
+	c.Request().PostArgs().VisitAll(func(key, value []byte) {
+		f.Values[string(key)] = string(value)
+	})
+	fields := apiErr.Metadata().Fields
+	for _, fe := range fields {
+		// Only show the first problem with each input so the form isn't cluttered
+		if _, ok := f.Errors[fe.Field]; !ok {
+			f.Errors[fe.Field] = fe.Message
+		}
+	}
+	if len(fields) == 0 {
+		f.Message = apiErr.Message()
+	}
+	return f, true
+}
+
+// sameOrigin returns whether the request was sent by a page from this server or by a client that isn't a browser.
+// Browsers send the credentials of the admin with every request to this server, even from forms on other sites,
+// so requests that could change data must be checked to prevent cross-site request forgery.
+func sameOrigin(c *fiber.Ctx) bool {
+	origin := c.Get(fiber.HeaderOrigin)
+	if origin == "" {
+		origin = c.Get(fiber.HeaderReferer)
+	}
+	if origin == "" {
+		// Browsers send Origin with every cross-site POST, so this didn't come from another site
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == string(c.Request().Host())
+}
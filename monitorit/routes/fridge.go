@@ -2,61 +2,287 @@ package routes
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/chart"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
 	"github.com/cszatmary/fridge-monitor/monitorit/models"
 	"github.com/gofiber/fiber/v2"
 )
 
 type FridgeHandler struct {
-	fm *models.FridgeManager
-	tm *models.TemperatureManager
+	fm  *models.FridgeManager
+	tm  *models.TemperatureManager
+	dm  *models.DeviceManager
+	am  *models.AlertManager
+	hub *events.Hub
 }
 
-func NewFridgeHandler(fm *models.FridgeManager, tm *models.TemperatureManager) *FridgeHandler {
-	return &FridgeHandler{fm, tm}
+func NewFridgeHandler(fm *models.FridgeManager, tm *models.TemperatureManager, dm *models.DeviceManager, am *models.AlertManager, hub *events.Hub) *FridgeHandler {
+	return &FridgeHandler{fm, tm, dm, am, hub}
 }
 
+// Headers used by sensors to identify the device sending temperatures.
+const (
+	headerDeviceSerial   = "X-Device-Serial"
+	headerDeviceFirmware = "X-Device-Firmware"
+	// maxDeviceHeaderLen is the max length of the device headers to prevent
+	// arbitrarily large values being stored.
+	maxDeviceHeaderLen = 128
+)
+
+// maxChannelLen is the max length of the name of a channel of a fridge.
+const maxChannelLen = 64
+
 type fridgeResponse struct {
-	ID            string  `json:"id"`
-	Name          string  `json:"name"`
-	Description   string  `json:"description"`
-	MinTemp       float64 `json:"minTemp"`
-	MaxTemp       float64 `json:"maxTemp"`
-	AlertsEnabled bool    `json:"alertsEnabled"`
+	ID               string   `json:"id"`
+	Name             string   `json:"name" form:"name"`
+	Description      string   `json:"description" form:"description"`
+	MinTemp          float64  `json:"minTemp" form:"minTemp"`
+	MaxTemp          float64  `json:"maxTemp" form:"maxTemp"`
+	AlertsEnabled    bool     `json:"alertsEnabled" form:"alertsEnabled"`
+	AlertSampleCount int      `json:"alertSampleCount" form:"alertSampleCount"`
+	MaxSilence       string   `json:"maxSilence" form:"maxSilence"`
+	TempHysteresis   float64  `json:"tempHysteresis" form:"tempHysteresis"`
+	MinHumidity      *float64 `json:"minHumidity" form:"minHumidity"`
+	MaxHumidity      *float64 `json:"maxHumidity" form:"maxHumidity"`
+	ChannelAggregate string   `json:"channelAggregate" form:"channelAggregate"`
+	ArchivedAt       *string  `json:"archivedAt"`
+}
+
+func newFridgeResponse(f models.Fridge) fridgeResponse {
+	resp := fridgeResponse{
+		ID:               strconv.FormatInt(f.ID, 10),
+		Name:             f.Name,
+		Description:      f.Description,
+		MinTemp:          f.MinTemp,
+		MaxTemp:          f.MaxTemp,
+		AlertsEnabled:    f.AlertsEnabled,
+		AlertSampleCount: f.AlertSampleCount,
+		MaxSilence:       f.MaxSilence.String(),
+		TempHysteresis:   f.TempHysteresis,
+		MinHumidity:      f.MinHumidity,
+		MaxHumidity:      f.MaxHumidity,
+		ChannelAggregate: string(f.ChannelAggregate),
+	}
+	if f.ArchivedAt.Valid {
+		s := f.ArchivedAt.Time.Format(time.RFC3339)
+		resp.ArchivedAt = &s
+	}
+	return resp
 }
 
+// fridgeSummary is the current state of a fridge shown on the dashboard.
+type fridgeSummary struct {
+	fridgeResponse
+	// Latest is the most recent temperature of the fridge, it is nil if none have been sent.
+	Latest *temperatureResponse
+	// LastReading is how long ago the latest temperature was sent, ex: 5m ago.
+	LastReading string
+	// Silent is true if the fridge hasn't sent a temperature within its max silence.
+	Silent            bool
+	MaxSilenceSeconds int64
+	// OpenAlertIDs are the IDs of the alerts of the fridge that aren't resolved.
+	OpenAlertIDs []string
+}
+
+// List returns all fridges that are in use. Archived fridges are returned instead if ?archived=true.
 func (fh *FridgeHandler) List(ctx context.Context, c *fiber.Ctx) (any, error) {
-	fridges, err := fh.fm.FindAll(ctx)
+	archived := c.Query("archived") == "true"
+	findAll := fh.fm.FindAll
+	if archived {
+		findAll = fh.fm.FindAllArchived
+	}
+	fridges, err := findAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 	body := struct {
 		Fridges []fridgeResponse `json:"fridges"`
-	}{Fridges: make([]fridgeResponse, len(fridges))}
+		// Only used by the view
+		Archived  bool            `json:"-"`
+		Summaries []fridgeSummary `json:"-"`
+	}{Fridges: make([]fridgeResponse, len(fridges)), Archived: archived}
 	for i, f := range fridges {
-		body.Fridges[i] = fridgeResponse{
-			ID:            strconv.FormatInt(f.ID, 10),
-			Name:          f.Name,
-			Description:   f.Description,
-			MinTemp:       f.MinTemp,
-			MaxTemp:       f.MaxTemp,
-			AlertsEnabled: f.AlertsEnabled,
+		body.Fridges[i] = newFridgeResponse(f)
+		if isHTML(c) && f.ArchivedAt.Valid {
+			archivedAt := f.ArchivedAt.Time.Local().Format(models.TimeFormatPretty)
+			body.Fridges[i].ArchivedAt = &archivedAt
 		}
 	}
+	if !isHTML(c) || archived {
+		return body, nil
+	}
+
+	// If html then also include the latest temperature and open alerts of each fridge so problems can be seen at a glance
+	alerts, err := fh.am.FindAllUnresolved(ctx)
+	if err != nil {
+		return nil, err
+	}
+	openAlertIDs := make(map[int64][]string)
+	for _, a := range alerts {
+		openAlertIDs[a.FridgeID] = append(openAlertIDs[a.FridgeID], strconv.FormatInt(a.ID, 10))
+	}
+	now := time.Now()
+	for i, f := range fridges {
+		summary := fridgeSummary{
+			fridgeResponse:    body.Fridges[i],
+			LastReading:       "never",
+			Silent:            true,
+			MaxSilenceSeconds: int64(f.MaxSilence / time.Second),
+			OpenAlertIDs:      openAlertIDs[f.ID],
+		}
+		temperatures, err := fh.tm.FindMostRecentByFridgeID(ctx, f.ID, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(temperatures) > 0 {
+			t := temperatures[0]
+			tr := newTemperatureResponse(t)
+			tr.Status = t.Status(f.MinTemp, f.MaxTemp).String()
+			summary.Latest = &tr
+			summary.LastReading = timeSince(t.CreatedAt.Time, now)
+			summary.Silent = now.Sub(t.CreatedAt.Time) >= f.MaxSilence
+		}
+		body.Summaries = append(body.Summaries, summary)
+	}
 	return body, nil
 }
 
+// timeSince formats the time elapsed since t in a short human readable form, ex: 5m ago.
+func timeSince(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", d/time.Minute)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dd ago", d/(24*time.Hour))
+	}
+}
+
+// formatDuration formats d without the units that are zero at the end, ex: 30m instead of 30m0s.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// New returns the form to create a fridge. It is only used by the view.
+func (fh *FridgeHandler) New(ctx context.Context, c *fiber.Ctx) (any, error) {
+	return form{
+		Action: "/fridges",
+		Values: map[string]string{
+			"alertsEnabled":    "true",
+			"alertSampleCount": strconv.Itoa(models.DefaultAlertSampleCount),
+			"maxSilence":       formatDuration(models.DefaultMaxSilence),
+			"tempHysteresis":   "0",
+			"channelAggregate": string(models.ChannelAggregateAny),
+		},
+	}, nil
+}
+
+// Edit returns the form to update the fridge filled in with its current values. It is only used by the view.
+func (fh *FridgeHandler) Edit(ctx context.Context, c *fiber.Ctx) (any, error) {
+	id, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	f, err := fh.fm.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	values := map[string]string{
+		"name":             f.Name,
+		"description":      f.Description,
+		"minTemp":          formatFloat(f.MinTemp),
+		"maxTemp":          formatFloat(f.MaxTemp),
+		"alertsEnabled":    strconv.FormatBool(f.AlertsEnabled),
+		"alertSampleCount": strconv.Itoa(f.AlertSampleCount),
+		"maxSilence":       formatDuration(f.MaxSilence),
+		"tempHysteresis":   formatFloat(f.TempHysteresis),
+		"channelAggregate": string(f.ChannelAggregate),
+	}
+	if f.MinHumidity != nil {
+		values["minHumidity"] = formatFloat(*f.MinHumidity)
+	}
+	if f.MaxHumidity != nil {
+		values["maxHumidity"] = formatFloat(*f.MaxHumidity)
+	}
+	return form{Action: fmt.Sprintf("/fridges/%d/edit", f.ID), Values: values}, nil
+}
+
 type temperatureResponse struct {
-	ID        string  `json:"id"`
-	Value     float64 `json:"value"`
-	Humidity  float64 `json:"humidity"`
-	CreatedAt string  `json:"createdAt"`
-	Status    string  `json:"-"`
+	ID    string  `json:"id"`
+	Value float64 `json:"value"`
+	// Humidity is nil if the sensor can't measure humidity
+	Humidity  *float64 `json:"humidity"`
+	Pressure  *float64 `json:"pressure"`
+	Channel   string   `json:"channel"`
+	CreatedAt string   `json:"createdAt"`
+	DeviceID  *string  `json:"deviceId,omitempty"`
+	Status    string   `json:"-"`
+	// HumidityStatus is only set if the fridge has a humidity range
+	HumidityStatus string `json:"-"`
+}
+
+func newTemperatureResponse(t models.Temperature) temperatureResponse {
+	tr := temperatureResponse{
+		ID:        strconv.FormatInt(t.ID, 10),
+		Value:     t.Value,
+		Humidity:  t.Humidity,
+		Pressure:  t.Pressure,
+		Channel:   t.Channel,
+		CreatedAt: t.CreatedAt.Format(time.RFC3339),
+	}
+	if t.DeviceID != nil {
+		deviceID := strconv.FormatInt(*t.DeviceID, 10)
+		tr.DeviceID = &deviceID
+	}
+	return tr
+}
+
+// rejectedTemperatureResponse is a temperature in a batch that wasn't created because it was invalid.
+type rejectedTemperatureResponse struct {
+	// Index is the position of the temperature in the request.
+	Index  int                   `json:"index"`
+	Fields []apierror.FieldError `json:"fields"`
+}
+
+// chartWindow is a time range that can be shown in the charts on the fridge page.
+type chartWindow struct {
+	Name       string
+	duration   time.Duration
+	bucket     time.Duration
+	timeFormat string
+}
+
+var chartWindows = []chartWindow{
+	{"24h", 24 * time.Hour, 5 * time.Minute, "15:04"},
+	{"7d", 7 * 24 * time.Hour, 30 * time.Minute, "Jan 2"},
+	{"30d", 30 * 24 * time.Hour, 2 * time.Hour, "Jan 2"},
 }
 
 func (fh *FridgeHandler) Get(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.FridgeHandler.Get")
 	id, err := paramInt64(c, "fridgeID")
 	if err != nil {
 		return nil, err
@@ -69,116 +295,882 @@ func (fh *FridgeHandler) Get(ctx context.Context, c *fiber.Ctx) (any, error) {
 	body := struct {
 		fridgeResponse
 		Temperatures []temperatureResponse `json:"temperatures,omitempty"`
+		// Only used by the view
+		Window           string           `json:"-"`
+		Windows          []chartWindow    `json:"-"`
+		TemperatureChart template.HTML    `json:"-"`
+		HumidityChart    template.HTML    `json:"-"`
+		Devices          []deviceResponse `json:"-"`
+		Alerts           []alertResponse  `json:"-"`
 	}{
-		fridgeResponse: fridgeResponse{
-			ID:            strconv.FormatInt(fridge.ID, 10),
-			Name:          fridge.Name,
-			Description:   fridge.Description,
-			MinTemp:       fridge.MinTemp,
-			MaxTemp:       fridge.MaxTemp,
-			AlertsEnabled: fridge.AlertsEnabled,
-		},
+		fridgeResponse: newFridgeResponse(fridge),
 	}
 	if isHTML(c) {
-		// If html then also include the last 5 temperatures to display in the view
+		if fridge.ArchivedAt.Valid {
+			archivedAt := fridge.ArchivedAt.Time.Local().Format(models.TimeFormatPretty)
+			body.ArchivedAt = &archivedAt
+		}
+		// If html then also include charts of the temperatures over the selected window
+		window := chartWindows[0]
+		if name := c.Query("window"); name != "" {
+			found := false
+			for _, w := range chartWindows {
+				if w.Name == name {
+					window, found = w, true
+					break
+				}
+			}
+			if !found {
+				return nil, apierror.New(
+					apierror.CodeInvalidParameter,
+					fmt.Sprintf("unknown window %q", name),
+					op,
+				)
+			}
+		}
+		body.Window = window.Name
+		body.Windows = chartWindows
+		body.TemperatureChart, body.HumidityChart, err = fh.renderCharts(ctx, fridge, window)
+		if err != nil {
+			return nil, err
+		}
+
+		// Also include the devices sending temperatures so dead sensors can be spotted
+		devices, err := fh.dm.FindAllByFridgeID(ctx, fridge.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range devices {
+			dr := newDeviceResponse(d, &fridge)
+			dr.LastSeenAt = d.LastSeenAt.Local().Format(models.TimeFormatPretty)
+			body.Devices = append(body.Devices, dr)
+		}
+
+		// Also include the open alerts so it's clear if anything is wrong
+		alerts, err := fh.am.FindAllUnresolvedByFridgeID(ctx, fridge.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range alerts {
+			body.Alerts = append(body.Alerts, newAlertResponse(a))
+		}
+
+		// Also include the last 5 temperatures to display in the view
 		temperatures, err := fh.tm.FindMostRecentByFridgeID(ctx, fridge.ID, 5)
 		if err != nil {
 			return nil, err
 		}
 		for _, t := range temperatures {
-			body.Temperatures = append(body.Temperatures, temperatureResponse{
+			tr := temperatureResponse{
 				ID:        strconv.FormatInt(t.ID, 10),
 				Value:     t.Value,
 				Humidity:  t.Humidity,
+				Pressure:  t.Pressure,
+				Channel:   t.Channel,
 				CreatedAt: t.CreatedAt.Local().Format(models.TimeFormatPretty),
 				Status:    t.Status(fridge.MinTemp, fridge.MaxTemp).String(),
-			})
+			}
+			if fridge.MinHumidity != nil || fridge.MaxHumidity != nil {
+				tr.HumidityStatus = t.HumidityStatus(fridge.MinHumidity, fridge.MaxHumidity).String()
+			}
+			body.Temperatures = append(body.Temperatures, tr)
 		}
 	}
 	return body, nil
 }
 
 func (fh *FridgeHandler) Create(ctx context.Context, c *fiber.Ctx) (any, error) {
-	var reqBody fridgeResponse
-	if err := c.BodyParser(&reqBody); err != nil {
+	const op = apierror.Op("routes.FridgeHandler.Create")
+	var reqBody struct {
+		Name          string  `json:"name" form:"name"`
+		Description   string  `json:"description" form:"description"`
+		MinTemp       float64 `json:"minTemp" form:"minTemp"`
+		MaxTemp       float64 `json:"maxTemp" form:"maxTemp"`
+		AlertsEnabled bool    `json:"alertsEnabled" form:"alertsEnabled"`
+		// Optional, nil uses the default. 0 is rejected the same as when updating.
+		AlertSampleCount *int     `json:"alertSampleCount" form:"alertSampleCount"`
+		MaxSilence       string   `json:"maxSilence" form:"maxSilence"`
+		TempHysteresis   float64  `json:"tempHysteresis" form:"tempHysteresis"`
+		MinHumidity      *float64 `json:"minHumidity" form:"minHumidity"`
+		MaxHumidity      *float64 `json:"maxHumidity" form:"maxHumidity"`
+		ChannelAggregate string   `json:"channelAggregate" form:"channelAggregate"`
+	}
+	if err := parseBody(c, &reqBody); err != nil {
 		return nil, err
 	}
-	f, err := fh.fm.InsertOne(ctx, models.Fridge{
-		Name:          reqBody.Name,
-		Description:   reqBody.Description,
-		MinTemp:       reqBody.MinTemp,
-		MaxTemp:       reqBody.MaxTemp,
-		AlertsEnabled: reqBody.AlertsEnabled,
-	})
+	fridge := models.Fridge{
+		Name:             reqBody.Name,
+		Description:      reqBody.Description,
+		MinTemp:          reqBody.MinTemp,
+		MaxTemp:          reqBody.MaxTemp,
+		AlertsEnabled:    reqBody.AlertsEnabled,
+		AlertSampleCount: models.DefaultAlertSampleCount,
+		MaxSilence:       models.DefaultMaxSilence,
+		TempHysteresis:   reqBody.TempHysteresis,
+		MinHumidity:      reqBody.MinHumidity,
+		MaxHumidity:      reqBody.MaxHumidity,
+		ChannelAggregate: models.ChannelAggregate(reqBody.ChannelAggregate),
+	}
+	if reqBody.AlertSampleCount != nil {
+		fridge.AlertSampleCount = *reqBody.AlertSampleCount
+	}
+	if fridge.ChannelAggregate == "" {
+		fridge.ChannelAggregate = models.ChannelAggregateAny
+	}
+	var v validator
+	if reqBody.MaxSilence != "" {
+		maxSilence, err := time.ParseDuration(reqBody.MaxSilence)
+		v.check(err == nil, "maxSilence", "must be a duration, ex: 30m")
+		fridge.MaxSilence = maxSilence
+	}
+	// Only check the other fields if maxSilence could be parsed, otherwise it would be reported twice
+	if len(v.fields) == 0 {
+		validateFridge(&v, fridge)
+	}
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+	f, err := fh.fm.InsertOne(ctx, fridge)
 	if err != nil {
 		return nil, err
 	}
-	return fridgeResponse{
-		ID:            strconv.FormatInt(f.ID, 10),
-		Name:          f.Name,
-		Description:   f.Description,
-		MinTemp:       f.MinTemp,
-		MaxTemp:       f.MaxTemp,
-		AlertsEnabled: f.AlertsEnabled,
-	}, nil
+	if isHTML(c) {
+		return redirect(fmt.Sprintf("/fridges/%d", f.ID)), nil
+	}
+	return newFridgeResponse(f), nil
 }
 
 func (fh *FridgeHandler) Update(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.FridgeHandler.Update")
 	id, err := paramInt64(c, "fridgeID")
 	if err != nil {
 		return nil, err
 	}
 	var reqBody struct {
-		Name          string   `json:"name"`
-		Description   *string  `json:"description"`
-		MinTemp       *float64 `json:"minTemp"`
-		MaxTemp       *float64 `json:"maxTemp"`
-		AlertsEnabled *bool    `json:"alertsEnabled"`
+		Name             string   `json:"name" form:"name"`
+		Description      *string  `json:"description" form:"description"`
+		MinTemp          *float64 `json:"minTemp" form:"minTemp"`
+		MaxTemp          *float64 `json:"maxTemp" form:"maxTemp"`
+		AlertsEnabled    *bool    `json:"alertsEnabled" form:"alertsEnabled"`
+		AlertSampleCount *int     `json:"alertSampleCount" form:"alertSampleCount"`
+		MaxSilence       *string  `json:"maxSilence" form:"maxSilence"`
+		TempHysteresis   *float64 `json:"tempHysteresis" form:"tempHysteresis"`
+		MinHumidity      *float64 `json:"minHumidity" form:"minHumidity"`
+		MaxHumidity      *float64 `json:"maxHumidity" form:"maxHumidity"`
+		// Set to true to stop monitoring humidity
+		ClearHumidityRange bool    `json:"clearHumidityRange" form:"clearHumidityRange"`
+		ChannelAggregate   *string `json:"channelAggregate" form:"channelAggregate"`
 	}
-	if err := c.BodyParser(&reqBody); err != nil {
+	if err := parseBody(c, &reqBody); err != nil {
 		return nil, err
 	}
 
-	f, err := fh.fm.UpdateOne(ctx, id, models.PartialFridge{
-		Name:          reqBody.Name,
-		Description:   reqBody.Description,
-		MinTemp:       reqBody.MinTemp,
-		MaxTemp:       reqBody.MaxTemp,
-		AlertsEnabled: reqBody.AlertsEnabled,
+	update := models.PartialFridge{
+		Name:             reqBody.Name,
+		Description:      reqBody.Description,
+		MinTemp:          reqBody.MinTemp,
+		MaxTemp:          reqBody.MaxTemp,
+		AlertsEnabled:    reqBody.AlertsEnabled,
+		AlertSampleCount: reqBody.AlertSampleCount,
+		TempHysteresis:   reqBody.TempHysteresis,
+		MinHumidity:      reqBody.MinHumidity,
+		MaxHumidity:      reqBody.MaxHumidity,
+		ClearMinHumidity: reqBody.ClearHumidityRange,
+		ClearMaxHumidity: reqBody.ClearHumidityRange,
+	}
+	if isForm(c) {
+		// An input that was left empty in a form means the value should be removed
+		args := c.Request().PostArgs()
+		if args.Has("description") && update.Description == nil {
+			description := ""
+			update.Description = &description
+		}
+		update.ClearMinHumidity = update.ClearMinHumidity || (args.Has("minHumidity") && update.MinHumidity == nil)
+		update.ClearMaxHumidity = update.ClearMaxHumidity || (args.Has("maxHumidity") && update.MaxHumidity == nil)
+	}
+	var v validator
+	if reqBody.MaxSilence != nil {
+		maxSilence, err := time.ParseDuration(*reqBody.MaxSilence)
+		v.check(err == nil, "maxSilence", "must be a duration, ex: 30m")
+		update.MaxSilence = &maxSilence
+	}
+	if reqBody.ChannelAggregate != nil {
+		agg := models.ChannelAggregate(*reqBody.ChannelAggregate)
+		update.ChannelAggregate = &agg
+	}
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+
+	// Validate the fridge as it will be after the update so that changing one field can't
+	// leave it in an invalid state, ex: setting minTemp above the current maxTemp
+	current, err := fh.fm.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	validateFridge(&v, applyFridgeUpdate(current, update))
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+
+	f, err := fh.fm.UpdateOne(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	if isHTML(c) {
+		return redirect(fmt.Sprintf("/fridges/%d", f.ID)), nil
+	}
+	return newFridgeResponse(f), nil
+}
+
+// Delete archives the fridge so it is hidden and no longer checked for alerts while keeping its history.
+// If ?hard=true, the fridge and all of its data are permanently deleted instead.
+func (fh *FridgeHandler) Delete(ctx context.Context, c *fiber.Ctx) (any, error) {
+	id, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	if c.Query("hard") == "true" {
+		f, err := fh.fm.FindOneByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := fh.fm.DeleteOne(ctx, id); err != nil {
+			return nil, err
+		}
+		return newFridgeResponse(f), nil
+	}
+
+	f, err := fh.fm.Archive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// The alert job no longer checks archived fridges so nothing would ever resolve them
+	resolved, err := fh.am.ResolveAllByFridgeID(ctx, id, fmt.Sprintf("%s was archived", f.Name))
+	if err != nil {
+		return nil, err
+	}
+	afterCommit(ctx, func() {
+		for _, a := range resolved {
+			fh.hub.Publish(events.Event{Type: events.TypeAlert, FridgeID: a.FridgeID, Data: a})
+		}
 	})
+	if isHTML(c) {
+		return redirect(fmt.Sprintf("/fridges/%d", f.ID)), nil
+	}
+	return newFridgeResponse(f), nil
+}
+
+const (
+	defaultTemperaturesLimit = 100
+	maxTemperaturesLimit     = 1000
+)
+
+// renderCharts renders SVG charts of the temperature and humidity of the fridge over the window.
+// Stats are used instead of raw temperatures so that the number of points is the same for every window.
+func (fh *FridgeHandler) renderCharts(ctx context.Context, fridge models.Fridge, window chartWindow) (template.HTML, template.HTML, error) {
+	end := time.Now()
+	start := end.Add(-window.duration)
+	stats, err := fh.tm.FindStatsByFridgeID(ctx, fridge.ID, nil, start, end, window.bucket)
+	if err != nil {
+		return "", "", err
+	}
+
+	tempChart := chart.Chart{
+		Width:      650,
+		Height:     250,
+		Start:      start.Local(),
+		End:        end.Local(),
+		Unit:       "°C",
+		TimeFormat: window.timeFormat,
+		MaxGap:     3 * window.bucket,
+		Band:       &chart.Band{Min: fridge.MinTemp, Max: fridge.MaxTemp},
+	}
+	humidityChart := tempChart
+	humidityChart.Unit = "%"
+	humidityChart.Band = nil
+	if fridge.MinHumidity != nil || fridge.MaxHumidity != nil {
+		band := chart.Band{Min: math.Inf(-1), Max: math.Inf(1)}
+		if fridge.MinHumidity != nil {
+			band.Min = *fridge.MinHumidity
+		}
+		if fridge.MaxHumidity != nil {
+			band.Max = *fridge.MaxHumidity
+		}
+		humidityChart.Band = &band
+	}
+
+	for _, s := range stats {
+		// Plot each bucket in the middle of its time range
+		t := s.BucketStart.Add(window.bucket / 2).Local()
+		tempChart.Points = append(tempChart.Points, chart.Point{
+			Time:  t,
+			Value: s.MeanValue,
+			Class: statusClass(s.Status(fridge.MinTemp, fridge.MaxTemp).String()),
+		})
+		if s.HumidityCount == 0 {
+			// The sensors of the fridge can't measure humidity, leave a gap instead of plotting 0
+			continue
+		}
+		humidityChart.Points = append(humidityChart.Points, chart.Point{
+			Time:  t,
+			Value: s.MeanHumidity,
+			Class: statusClass(s.HumidityStatus(fridge.MinHumidity, fridge.MaxHumidity).String()),
+		})
+	}
+	// The SVG is generated by us and all text in it is escaped so it is safe to include as is
+	return template.HTML(tempChart.SVG()), template.HTML(humidityChart.SVG()), nil
+}
+
+// statusClass returns the CSS class used to display a status.
+// Normal statuses have no class since they don't need to be highlighted.
+func statusClass(status string) string {
+	switch status {
+	case models.StatusTooLow.String():
+		return "too-low"
+	case models.StatusTooHigh.String():
+		return "too-high"
+	default:
+		return ""
+	}
+}
+
+// ListTemperatures returns the temperatures for a fridge in a time range.
+// Results are paginated, if there are more temperatures nextCursor is set and can be passed
+// as the cursor query parameter to get the next page. The channel query parameter can be used
+// to only return temperatures for a single channel.
+func (fh *FridgeHandler) ListTemperatures(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.FridgeHandler.ListTemperatures")
+	fridgeID, err := paramInt64(c, "fridgeID")
 	if err != nil {
 		return nil, err
 	}
-	return fridgeResponse{
-		ID:            strconv.FormatInt(f.ID, 10),
-		Name:          f.Name,
-		Description:   f.Description,
-		MinTemp:       f.MinTemp,
-		MaxTemp:       f.MaxTemp,
-		AlertsEnabled: f.AlertsEnabled,
-	}, nil
+	q := models.TemperatureQuery{FridgeID: fridgeID}
+	if q.From, err = queryTime(c, "from"); err != nil {
+		return nil, err
+	}
+	if q.To, err = queryTime(c, "to"); err != nil {
+		return nil, err
+	}
+	limit, err := queryInt(c, "limit", defaultTemperaturesLimit)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > maxTemperaturesLimit {
+		return nil, apierror.New(
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("limit must be between 1 and %d", maxTemperaturesLimit),
+			op,
+		)
+	}
+	switch order := c.Query("order", "asc"); order {
+	case "asc":
+	case "desc":
+		q.Descending = true
+	default:
+		return nil, apierror.New(
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("order must be asc or desc, got %q", order),
+			op,
+		)
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeTemperatureCursor(raw)
+		if err != nil {
+			return nil, apierror.Wrap(
+				err,
+				apierror.CodeInvalidParameter,
+				fmt.Sprintf("invalid cursor %q", raw),
+				op,
+			)
+		}
+		q.After = &cursor
+	}
+	// Check if the param is present instead of non-empty so the default channel can be filtered by
+	if c.Context().QueryArgs().Has("channel") {
+		channel := c.Query("channel")
+		q.Channel = &channel
+	}
+
+	// Make sure the fridge exists so a 404 is returned instead of an empty list
+	if _, err := fh.fm.FindOneByID(ctx, fridgeID); err != nil {
+		return nil, err
+	}
+	// Fetch an extra temperature to know if there is another page
+	q.Limit = limit + 1
+	temps, err := fh.tm.FindByFridgeID(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Temperatures []temperatureResponse `json:"temperatures"`
+		NextCursor   *string               `json:"nextCursor"`
+	}{}
+	if len(temps) > limit {
+		temps = temps[:limit]
+		last := temps[len(temps)-1]
+		cursor := encodeTemperatureCursor(models.TemperatureCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		body.NextCursor = &cursor
+	}
+	body.Temperatures = make([]temperatureResponse, len(temps))
+	for i, t := range temps {
+		body.Temperatures[i] = newTemperatureResponse(t)
+	}
+	return body, nil
+}
+
+// encodeTemperatureCursor encodes the cursor as an opaque string that can be given to clients.
+func encodeTemperatureCursor(cursor models.TemperatureCursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.Unix(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTemperatureCursor(s string) (models.TemperatureCursor, error) {
+	var cursor models.TemperatureCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	var createdAt int64
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &createdAt, &cursor.ID); err != nil {
+		return cursor, err
+	}
+	cursor.CreatedAt = models.Time{Time: time.Unix(createdAt, 0).UTC()}
+	return cursor, nil
+}
+
+const maxStatsBuckets = 10000
+
+type statsValuesResponse struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stdDev"`
+}
+
+type statsBucketResponse struct {
+	Start string              `json:"start"`
+	Count int                 `json:"count"`
+	Value statsValuesResponse `json:"value"`
+	// Humidity is nil if none of the temperatures in the bucket had a humidity
+	Humidity *statsValuesResponse `json:"humidity"`
+	// Pressure is nil if none of the temperatures in the bucket had a pressure
+	Pressure *statsValuesResponse `json:"pressure"`
+}
+
+// TemperatureStats returns statistics for the temperatures of a fridge in a time range grouped into buckets.
+// The time range defaults to the last 24h and the bucket size defaults to 1h.
+// All channels are included unless ?channel is set.
+func (fh *FridgeHandler) TemperatureStats(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.FridgeHandler.TemperatureStats")
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return nil, err
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-24 * time.Hour)
+	}
+	if !from.Before(to) {
+		return nil, apierror.New(apierror.CodeInvalidParameter, "from must be before to", op)
+	}
+	bucket, err := parseBucket(c.Query("bucket", "1h"))
+	if err != nil {
+		return nil, apierror.Wrap(
+			err,
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("invalid bucket %q", c.Query("bucket")),
+			op,
+		)
+	}
+	if bucket < time.Minute {
+		return nil, apierror.New(apierror.CodeInvalidParameter, "bucket must be at least 1m", op)
+	}
+	if to.Sub(from)/bucket > maxStatsBuckets {
+		return nil, apierror.New(
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("time range is too large for the bucket size, at most %d buckets are allowed", maxStatsBuckets),
+			op,
+		)
+	}
+
+	// Check if the param is present instead of non-empty so the default channel can be filtered by
+	var channel *string
+	if c.Context().QueryArgs().Has("channel") {
+		ch := c.Query("channel")
+		channel = &ch
+	}
+
+	if _, err := fh.fm.FindOneByID(ctx, fridgeID); err != nil {
+		return nil, err
+	}
+	stats, err := fh.tm.FindStatsByFridgeID(ctx, fridgeID, channel, from, to, bucket)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		From    string                `json:"from"`
+		To      string                `json:"to"`
+		Bucket  string                `json:"bucket"`
+		Buckets []statsBucketResponse `json:"buckets"`
+	}{
+		From:    from.UTC().Format(time.RFC3339),
+		To:      to.UTC().Format(time.RFC3339),
+		Bucket:  bucket.String(),
+		Buckets: make([]statsBucketResponse, len(stats)),
+	}
+	for i, s := range stats {
+		body.Buckets[i] = statsBucketResponse{
+			Start: s.BucketStart.Format(time.RFC3339),
+			Count: s.Count,
+			Value: statsValuesResponse{
+				Min:    s.MinValue,
+				Max:    s.MaxValue,
+				Mean:   s.MeanValue,
+				StdDev: s.StdDevValue,
+			},
+		}
+		if s.HumidityCount > 0 {
+			body.Buckets[i].Humidity = &statsValuesResponse{
+				Min:    s.MinHumidity,
+				Max:    s.MaxHumidity,
+				Mean:   s.MeanHumidity,
+				StdDev: s.StdDevHumidity,
+			}
+		}
+		if s.PressureCount > 0 {
+			body.Buckets[i].Pressure = &statsValuesResponse{
+				Min:    s.MinPressure,
+				Max:    s.MaxPressure,
+				Mean:   s.MeanPressure,
+				StdDev: s.StdDevPressure,
+			}
+		}
+	}
+	return body, nil
+}
+
+// parseBucket parses a bucket size. It is the same as time.ParseDuration
+// except it also supports days with the d suffix, ex: 1d.
+func parseBucket(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.ParseInt(strings.TrimSuffix(s, "d"), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		// Check the range before multiplying since a large number of days would overflow
+		if n <= 0 || n > math.MaxInt64/int64(24*time.Hour) {
+			return 0, fmt.Errorf("number of days must be between 1 and %d", math.MaxInt64/int64(24*time.Hour))
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
 }
 
 func (fh *FridgeHandler) CreateTemperature(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.FridgeHandler.CreateTemperature")
 	fridgeID, err := paramInt64(c, "fridgeID")
 	if err != nil {
 		return nil, err
 	}
 	var reqBody struct {
-		Value    float64 `json:"value"`
-		Humidity float64 `json:"humidity"`
+		Value float64 `json:"value"`
+		// Optional, nil if the sensor can't measure humidity
+		Humidity *float64 `json:"humidity"`
+		// Optional, in hPa
+		Pressure *float64 `json:"pressure"`
+		Channel  string   `json:"channel"`
 	}
-	if err := c.BodyParser(&reqBody); err != nil {
+	if err := parseBody(c, &reqBody); err != nil {
 		return nil, err
 	}
-	temp, err := fh.tm.InsertOne(ctx, fridgeID, reqBody.Value, reqBody.Humidity)
+	var v validator
+	v.checkTemperature(reqBody.Value, "value")
+	if reqBody.Humidity != nil {
+		v.checkHumidity(*reqBody.Humidity, "humidity")
+	}
+	v.checkPressure(reqBody.Pressure, "pressure")
+	v.check(len(reqBody.Channel) <= maxChannelLen, "channel", "must be at most %d characters", maxChannelLen)
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+	temp := models.Temperature{
+		Value:    reqBody.Value,
+		Humidity: reqBody.Humidity,
+		Pressure: reqBody.Pressure,
+		FridgeID: fridgeID,
+		Channel:  reqBody.Channel,
+	}
+
+	if err := fh.checkActive(ctx, fridgeID, op); err != nil {
+		return nil, err
+	}
+	if temp.DeviceID, err = fh.recordDevice(ctx, c, fridgeID, op); err != nil {
+		return nil, err
+	}
+	temp, err = fh.tm.InsertOne(ctx, temp)
 	if err != nil {
 		return nil, err
 	}
-	return temperatureResponse{
-		ID:        strconv.FormatInt(temp.ID, 10),
-		Value:     temp.Value,
-		Humidity:  temp.Humidity,
-		CreatedAt: temp.CreatedAt.Format(time.RFC3339),
-	}, nil
+	afterCommit(ctx, func() {
+		fh.hub.Publish(events.Event{Type: events.TypeTemperature, FridgeID: fridgeID, Data: temp})
+	})
+	return newTemperatureResponse(temp), nil
+}
+
+const (
+	// maxBatchSize is the max number of temperatures that can be created in a single batch.
+	maxBatchSize = 1000
+	// maxClockSkew is how far in the future a client provided timestamp can be.
+	// This allows for some drift between the sensor and server clocks.
+	maxClockSkew = 5 * time.Minute
+	// maxBatchAge is how far in the past a client provided timestamp can be.
+	maxBatchAge = 7 * 24 * time.Hour
+)
+
+// CreateTemperatures creates multiple temperatures at once. Each temperature can have a createdAt
+// timestamp provided by the client which allows sensors to upload temperatures that were recorded
+// while they were unable to reach the server. Invalid temperatures are skipped and returned in rejected
+// so that a single bad reading doesn't cause the sensor to lose the rest of the batch.
+// If none of the temperatures are valid, the request fails with a validation error.
+func (fh *FridgeHandler) CreateTemperatures(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.FridgeHandler.CreateTemperatures")
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	var reqBody struct {
+		Temperatures []struct {
+			Value float64 `json:"value"`
+			// Optional, nil if the sensor can't measure humidity
+			Humidity *float64 `json:"humidity"`
+			// Optional, in hPa
+			Pressure *float64 `json:"pressure"`
+			Channel  string   `json:"channel"`
+			// Optional, defaults to the current time
+			CreatedAt *time.Time `json:"createdAt"`
+		} `json:"temperatures"`
+	}
+	if err := parseBody(c, &reqBody); err != nil {
+		return nil, err
+	}
+	var allErrs validator
+	allErrs.check(
+		len(reqBody.Temperatures) > 0 && len(reqBody.Temperatures) <= maxBatchSize,
+		"temperatures",
+		"must contain between 1 and %d temperatures", maxBatchSize,
+	)
+	if err := allErrs.err(op); err != nil {
+		return nil, err
+	}
+
+	if err := fh.checkActive(ctx, fridgeID, op); err != nil {
+		return nil, err
+	}
+	deviceID, err := fh.recordDevice(ctx, c, fridgeID, op)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	temps := make([]models.Temperature, 0, len(reqBody.Temperatures))
+	rejected := make([]rejectedTemperatureResponse, 0)
+	for i, rt := range reqBody.Temperatures {
+		// Each temperature is validated separately so that only the invalid ones are rejected
+		var v validator
+		field := fmt.Sprintf("temperatures[%d]", i)
+		v.checkTemperature(rt.Value, field+".value")
+		if rt.Humidity != nil {
+			v.checkHumidity(*rt.Humidity, field+".humidity")
+		}
+		v.checkPressure(rt.Pressure, field+".pressure")
+		v.check(len(rt.Channel) <= maxChannelLen, field+".channel", "must be at most %d characters", maxChannelLen)
+		t := models.Temperature{
+			Value:    rt.Value,
+			Humidity: rt.Humidity,
+			Pressure: rt.Pressure,
+			FridgeID: fridgeID,
+			Channel:  rt.Channel,
+			DeviceID: deviceID,
+		}
+		if rt.CreatedAt != nil {
+			// Reject timestamps that are too far off since it likely means the sensor's clock is wrong
+			switch createdAt := *rt.CreatedAt; {
+			case createdAt.After(now.Add(maxClockSkew)):
+				v.check(false, field+".createdAt", "%s is in the future, check the clock of the sensor", createdAt.Format(time.RFC3339))
+			case createdAt.Before(now.Add(-maxBatchAge)):
+				v.check(false, field+".createdAt", "%s is more than %s in the past", createdAt.Format(time.RFC3339), maxBatchAge)
+			case createdAt.After(now):
+				// Within the allowed skew, clamp it so that temperatures are never in the future
+				t.CreatedAt = models.Time{Time: now}
+			default:
+				t.CreatedAt = models.Time{Time: createdAt}
+			}
+		}
+		if len(v.fields) > 0 {
+			rejected = append(rejected, rejectedTemperatureResponse{Index: i, Fields: v.fields})
+			allErrs.fields = append(allErrs.fields, v.fields...)
+			continue
+		}
+		temps = append(temps, t)
+	}
+
+	if len(temps) == 0 {
+		return nil, allErrs.err(op)
+	}
+
+	inserted, err := fh.tm.InsertMany(ctx, temps)
+	if err != nil {
+		return nil, err
+	}
+	// Only publish the latest temperature of each channel. The rest were buffered by the sensor
+	// and are already out of date, there could also be more than subscribers can keep up with.
+	latest := make(map[string]models.Temperature)
+	var channels []string
+	for _, t := range inserted {
+		l, ok := latest[t.Channel]
+		if !ok {
+			channels = append(channels, t.Channel)
+		}
+		if !ok || !t.CreatedAt.Before(l.CreatedAt.Time) {
+			latest[t.Channel] = t
+		}
+	}
+	afterCommit(ctx, func() {
+		for _, ch := range channels {
+			fh.hub.Publish(events.Event{Type: events.TypeTemperature, FridgeID: fridgeID, Data: latest[ch]})
+		}
+	})
+	body := struct {
+		Temperatures []temperatureResponse         `json:"temperatures"`
+		Rejected     []rejectedTemperatureResponse `json:"rejected"`
+	}{Temperatures: make([]temperatureResponse, len(inserted)), Rejected: rejected}
+	for i, t := range inserted {
+		body.Temperatures[i] = newTemperatureResponse(t)
+	}
+	return body, nil
+}
+
+// checkActive checks that the fridge exists and isn't archived so temperatures can be created for it.
+func (fh *FridgeHandler) checkActive(ctx context.Context, fridgeID int64, op apierror.Op) error {
+	f, err := fh.fm.FindOneByID(ctx, fridgeID)
+	if err != nil {
+		return err
+	}
+	if f.ArchivedAt.Valid {
+		return apierror.New(
+			apierror.CodeConflict,
+			fmt.Sprintf("%s is archived and no longer accepts temperatures", f.Name),
+			op,
+		)
+	}
+	return nil
+}
+
+// recordDevice records the device that sent temperatures for the fridge if it identified itself
+// using the device headers. The ID of the device is returned, or nil if it didn't identify itself.
+func (fh *FridgeHandler) recordDevice(ctx context.Context, c *fiber.Ctx, fridgeID int64, op apierror.Op) (*int64, error) {
+	serial := strings.TrimSpace(c.Get(headerDeviceSerial))
+	firmware := strings.TrimSpace(c.Get(headerDeviceFirmware))
+	if len(serial) > maxDeviceHeaderLen || len(firmware) > maxDeviceHeaderLen {
+		return nil, apierror.New(
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("%s and %s must be at most %d characters", headerDeviceSerial, headerDeviceFirmware, maxDeviceHeaderLen),
+			op,
+		)
+	}
+	if serial == "" {
+		return nil, nil
+	}
+	d, err := fh.dm.Record(ctx, serial, firmware, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	return &d.ID, nil
+}
+
+// validateFridge checks that all the settings of the fridge are valid.
+func validateFridge(v *validator, f models.Fridge) {
+	v.checkName(f.Name, "name")
+	v.checkTemperature(f.MinTemp, "minTemp")
+	v.checkTemperature(f.MaxTemp, "maxTemp")
+	v.check(f.MinTemp < f.MaxTemp, "minTemp", "must be less than maxTemp (%g)", f.MaxTemp)
+	v.check(f.AlertSampleCount >= 1, "alertSampleCount", "must be at least 1")
+	v.check(f.MaxSilence >= time.Minute, "maxSilence", "must be at least 1m")
+	v.check(f.TempHysteresis >= 0, "tempHysteresis", "must not be negative")
+	if f.MinTemp < f.MaxTemp {
+		// An alert is only resolved once the temperature is at least tempHysteresis inside the safe range.
+		// If the margins on both sides overlap that can never happen and alerts would never be resolved.
+		v.check(
+			2*f.TempHysteresis < f.MaxTemp-f.MinTemp,
+			"tempHysteresis",
+			"must be less than half the safe range (%g)", (f.MaxTemp-f.MinTemp)/2,
+		)
+	}
+	if f.MinHumidity != nil {
+		v.checkHumidity(*f.MinHumidity, "minHumidity")
+	}
+	if f.MaxHumidity != nil {
+		v.checkHumidity(*f.MaxHumidity, "maxHumidity")
+	}
+	if f.MinHumidity != nil && f.MaxHumidity != nil {
+		v.check(*f.MinHumidity <= *f.MaxHumidity, "minHumidity", "must not be greater than maxHumidity (%g)", *f.MaxHumidity)
+	}
+	v.check(
+		f.ChannelAggregate.Valid(),
+		"channelAggregate",
+		"must be one of %q, %q, or %q", models.ChannelAggregateAny, models.ChannelAggregateMax, models.ChannelAggregateMean,
+	)
+}
+
+// applyFridgeUpdate returns the fridge with the changes in update applied.
+func applyFridgeUpdate(f models.Fridge, update models.PartialFridge) models.Fridge {
+	if update.Name != "" {
+		f.Name = update.Name
+	}
+	if update.Description != nil {
+		f.Description = *update.Description
+	}
+	if update.MinTemp != nil {
+		f.MinTemp = *update.MinTemp
+	}
+	if update.MaxTemp != nil {
+		f.MaxTemp = *update.MaxTemp
+	}
+	if update.AlertsEnabled != nil {
+		f.AlertsEnabled = *update.AlertsEnabled
+	}
+	if update.AlertSampleCount != nil {
+		f.AlertSampleCount = *update.AlertSampleCount
+	}
+	if update.MaxSilence != nil {
+		f.MaxSilence = *update.MaxSilence
+	}
+	if update.TempHysteresis != nil {
+		f.TempHysteresis = *update.TempHysteresis
+	}
+	if update.ClearMinHumidity {
+		f.MinHumidity = nil
+	} else if update.MinHumidity != nil {
+		f.MinHumidity = update.MinHumidity
+	}
+	if update.ClearMaxHumidity {
+		f.MaxHumidity = nil
+	} else if update.MaxHumidity != nil {
+		f.MaxHumidity = update.MaxHumidity
+	}
+	if update.ChannelAggregate != nil {
+		f.ChannelAggregate = *update.ChannelAggregate
+	}
+	return f
 }
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"minutes", "5m", 5 * time.Minute, false},
+		{"hours", "1h", time.Hour, false},
+		{"mixed", "1h30m", 90 * time.Minute, false},
+		{"one day", "1d", 24 * time.Hour, false},
+		{"days", "7d", 7 * 24 * time.Hour, false},
+		{"max days", "106751d", 106751 * 24 * time.Hour, false},
+		// Would wrap around to about 25 minutes if not checked
+		{"overflow", "213504d", 0, true},
+		// Would wrap around to a negative duration if not checked
+		{"large overflow", "200000d", 0, true},
+		{"zero days", "0d", 0, true},
+		{"negative days", "-1d", 0, true},
+		{"fractional days", "1.5d", 0, true},
+		{"no number", "d", 0, true},
+		{"invalid", "abc", 0, true},
+		{"empty", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBucket(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseBucket(%q) = %s, want error", tt.s, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBucket(%q) failed: %v", tt.s, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseBucket(%q) = %s, want %s", tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/events"
 	"github.com/cszatmary/fridge-monitor/monitorit/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -20,15 +22,33 @@ import (
 // Set during docker build
 var gitsha = "unavailable"
 
+// requestIDKey is the key of the ID of the request in the locals of the fiber context.
+// The ID is also returned in the X-Request-ID header so errors can be matched with the logs.
+const requestIDKey = "requestid"
+
 type errorResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// RequestID identifies the request in the logs.
+	RequestID string `json:"requestId"`
+	// Fields has the problems with each invalid field if the request failed validation.
+	Fields []apierror.FieldError `json:"fields,omitempty"`
+	// RetryAfter is how many seconds to wait before retrying the request.
+	RetryAfter int    `json:"retryAfter,omitempty"`
+	DocURL     string `json:"docUrl,omitempty"`
 }
 
 type SetupDependencies struct {
 	DB                 *sql.DB
 	FridgeManager      *models.FridgeManager
 	TemperatureManager *models.TemperatureManager
+	ContactManager     *models.ContactManager
+	AlertManager       *models.AlertManager
+	DeviceManager      *models.DeviceManager
+	SensorTokenManager *models.SensorTokenManager
+	AdminCredentials   AdminCredentials
+	// Events are published to the hub when temperatures and alerts are created or changed.
+	Events *events.Hub
 }
 
 func SetupApp(deps SetupDependencies) *fiber.App {
@@ -37,50 +57,70 @@ func SetupApp(deps SetupDependencies) *fiber.App {
 		ViewsLayout: "layouts/page",
 		AppName:     "MonitorIt",
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			requestID, _ := c.Locals(requestIDKey).(string)
 			code := apierror.CodeUnknown
 			errorResp := errorResponse{
-				Code:    code.String(),
-				Message: "An unknown error occurred",
+				Code:      code.String(),
+				Message:   "An unknown error occurred",
+				RequestID: requestID,
 			}
 
+			// Default status code to 500
+			status := fiber.StatusInternalServerError
 			var apiErr apierror.Error
+			var fiberErr *fiber.Error
 			if errors.As(err, &apiErr) {
 				code = apiErr.Code()
+				status = statusForCode(code)
 				errorResp.Code = code.String()
 				errorResp.Message = apiErr.Message()
+				meta := apiErr.Metadata()
+				errorResp.Fields = meta.Fields
+				errorResp.DocURL = meta.DocURL
+				if meta.RetryAfter > 0 {
+					// Round up so clients never retry too early
+					seconds := int((meta.RetryAfter + time.Second - 1) / time.Second)
+					errorResp.RetryAfter = seconds
+					c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+				}
+			} else if errors.As(err, &fiberErr) {
+				// Errors from fiber itself, ex: no route matched
+				status = fiberErr.Code
+				errorResp.Message = fiberErr.Message
 			}
 
 			var detailedErr apierror.DetailedError
 			if errors.As(err, &detailedErr) {
-				log.Printf("Error: %s", detailedErr.Details())
-			}
-
-			// Default status code to 500
-			status := fiber.StatusInternalServerError
-			switch code {
-			case apierror.CodeDatabase:
-				status = fiber.StatusInternalServerError
-			case apierror.CodeRecordNotFound:
-				status = fiber.StatusNotFound
-			case apierror.CodeInvalidParameter:
-				status = fiber.StatusBadRequest
+				log.Printf("Error: request %s: %s", requestID, detailedErr.Details())
+			} else if status >= fiber.StatusInternalServerError {
+				log.Printf("Error: request %s: %v", requestID, err)
 			}
 
 			body := struct {
 				Error  errorResponse `json:"error"`
 				Status int           `json:"-"`
 			}{Error: errorResp, Status: status}
+			c.Status(status)
 			if isHTML(c) {
 				return c.Render("error", body)
 			}
 			return c.JSON(body)
 		},
 	})
-	app.Use(requestid.New())
-	app.Use(logger.New())
+	app.Use(requestid.New(requestid.Config{ContextKey: requestIDKey}))
+	app.Use(logger.New(logger.Config{
+		Format: "[${time}] ${locals:" + requestIDKey + "} ${status} - ${latency} ${method} ${path}\n",
+	}))
 	app.Use(recovermw.New())
 
-	fh := NewFridgeHandler(deps.FridgeManager, deps.TemperatureManager)
+	fh := NewFridgeHandler(deps.FridgeManager, deps.TemperatureManager, deps.DeviceManager, deps.AlertManager, deps.Events)
+	ch := NewContactHandler(deps.FridgeManager, deps.ContactManager)
+	ah := NewAlertHandler(deps.AlertManager, deps.Events)
+	eh := NewEventHandler(deps.FridgeManager, deps.Events)
+	dh := NewDeviceHandler(deps.FridgeManager, deps.TemperatureManager, deps.DeviceManager)
+	sth := NewSensorTokenHandler(deps.FridgeManager, deps.SensorTokenManager)
+	admin := requireAdmin(deps.AdminCredentials)
+	sensor := requireSensorToken(deps.SensorTokenManager, deps.AdminCredentials)
 
 	app.Get("/ping", func(c *fiber.Ctx) error {
 		return c.SendString("MonitorIt OK: " + gitsha)
@@ -90,21 +130,82 @@ func SetupApp(deps SetupDependencies) *fiber.App {
 		return c.Redirect("/fridges")
 	})
 	app.Get("/fridges", createHandler("fridges/index", fh.List))
-	app.Post("/fridges", createHandler("", withTransaction(deps.DB, fh.Create)))
+	app.Post("/fridges", admin, createHandler("fridges/new", withTransaction(deps.DB, fh.Create)))
+	// Must be before /fridges/:fridgeID so new isn't treated as an ID
+	app.Get("/fridges/new", admin, createHandler("fridges/new", fh.New))
 	app.Get("/fridges/:fridgeID", createHandler("fridges/show", fh.Get))
-	app.Patch("/fridges/:fridgeID", createHandler("", withTransaction(deps.DB, fh.Update)))
-	app.Post("/fridges/:fridgeID/temperatures", createHandler("", withTransaction(deps.DB, fh.CreateTemperature)))
+	app.Patch("/fridges/:fridgeID", admin, createHandler("", withTransaction(deps.DB, fh.Update)))
+	app.Delete("/fridges/:fridgeID", admin, createHandler("", withTransaction(deps.DB, fh.Delete)))
+	// HTML forms can only send GET and POST requests so they have their own routes
+	app.Get("/fridges/:fridgeID/edit", admin, createHandler("fridges/edit", fh.Edit))
+	app.Post("/fridges/:fridgeID/edit", admin, createHandler("fridges/edit", withTransaction(deps.DB, fh.Update)))
+	app.Post("/fridges/:fridgeID/archive", admin, createHandler("", withTransaction(deps.DB, fh.Delete)))
+	app.Get("/fridges/:fridgeID/events", eh.Stream)
+	app.Get("/fridges/:fridgeID/temperatures", createHandler("", fh.ListTemperatures))
+	app.Get("/fridges/:fridgeID/temperatures/stats", createHandler("", fh.TemperatureStats))
+	app.Get("/fridges/:fridgeID/temperatures/export", fh.ExportTemperatures)
+	app.Post("/fridges/:fridgeID/temperatures", sensor, createHandler("", withTransaction(deps.DB, fh.CreateTemperature)))
+	app.Post("/fridges/:fridgeID/temperatures/batch", sensor, createHandler("", withTransaction(deps.DB, fh.CreateTemperatures)))
+	app.Get("/fridges/:fridgeID/contacts", admin, createHandler("", ch.List))
+	app.Post("/fridges/:fridgeID/contacts", admin, createHandler("", withTransaction(deps.DB, ch.Create)))
+	app.Get("/fridges/:fridgeID/contacts/:contactID", admin, createHandler("", ch.Get))
+	app.Patch("/fridges/:fridgeID/contacts/:contactID", admin, createHandler("", withTransaction(deps.DB, ch.Update)))
+	app.Delete("/fridges/:fridgeID/contacts/:contactID", admin, createHandler("", withTransaction(deps.DB, ch.Delete)))
+	app.Get("/fridges/:fridgeID/tokens", admin, createHandler("", sth.List))
+	app.Post("/fridges/:fridgeID/tokens", admin, createHandler("", withTransaction(deps.DB, sth.Create)))
+	app.Delete("/fridges/:fridgeID/tokens/:tokenID", admin, createHandler("", withTransaction(deps.DB, sth.Delete)))
+	app.Get("/devices", createHandler("devices/index", dh.List))
+	app.Get("/devices/:deviceID", createHandler("devices/show", dh.Get))
+	app.Get("/alerts", createHandler("", ah.List))
+	app.Get("/alerts/:alertID", createHandler("", ah.Get))
+	app.Post("/alerts/:alertID/ack", admin, createHandler("", withTransaction(deps.DB, ah.Acknowledge)))
+	app.Get("/events", eh.Stream)
 	return app
 }
 
+// statusForCode returns the HTTP status code of the response for an error with the code.
+func statusForCode(code apierror.Code) int {
+	switch code {
+	case apierror.CodeRecordNotFound:
+		return fiber.StatusNotFound
+	case apierror.CodeInvalidParameter:
+		return fiber.StatusBadRequest
+	case apierror.CodeUnauthorized:
+		return fiber.StatusUnauthorized
+	case apierror.CodeForbidden:
+		return fiber.StatusForbidden
+	case apierror.CodeValidation:
+		return fiber.StatusUnprocessableEntity
+	case apierror.CodeConflict:
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
 type handler func(context.Context, *fiber.Ctx) (any, error)
 
+// createHandler creates a fiber handler from h. If the client accepts HTML and templateName is set,
+// the data returned by h is rendered with the template, otherwise it is sent as JSON.
+// For HTML form submissions, templateName is the form and it is shown again with the problems if the values are invalid.
 func createHandler(templateName string, h handler) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		data, err := h(c.Context(), c)
 		if err != nil {
+			if templateName != "" && isForm(c) && isHTML(c) {
+				if f, ok := formFromError(c, err); ok {
+					var apiErr apierror.Error
+					errors.As(err, &apiErr)
+					c.Status(statusForCode(apiErr.Code()))
+					return c.Render(templateName, f)
+				}
+			}
 			return err
 		}
+		if path, ok := data.(redirect); ok {
+			// 303 so the browser follows it with a GET instead of submitting the form again
+			return c.Redirect(string(path), fiber.StatusSeeOther)
+		}
 		if templateName != "" && isHTML(c) {
 			return c.Render(templateName, data)
 		}
@@ -127,6 +228,8 @@ func withTransaction(db *sql.DB, h handler) handler {
 
 		// Add the txn to the context so it can be used by handlers
 		ctx = models.ContextWithTxn(ctx, txn)
+		var hooks []func()
+		ctx = context.WithValue(ctx, afterCommitKey{}, &hooks)
 
 		// Handle end of request in a defer
 		// That way we can easily handle success, failure, and panic in one place
@@ -156,6 +259,10 @@ func withTransaction(db *sql.DB, h handler) handler {
 			commitErr := txn.Commit()
 			if commitErr != nil {
 				log.Printf("Failed to commit database transaction: %v", commitErr)
+				return
+			}
+			for _, hook := range hooks {
+				hook()
 			}
 		}()
 
@@ -164,6 +271,18 @@ func withTransaction(db *sql.DB, h handler) handler {
 	})
 }
 
+type afterCommitKey struct{}
+
+// afterCommit registers f to be called once the transaction of the request has been committed.
+// If the transaction is rolled back f is never called. It must be used in a handler wrapped with withTransaction.
+func afterCommit(ctx context.Context, f func()) {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*[]func())
+	if !ok {
+		panic("afterCommit must be called within a transaction")
+	}
+	*hooks = append(*hooks, f)
+}
+
 func isHTML(c *fiber.Ctx) bool {
 	return c.Accepts("application/json", "text/html") == "text/html"
 }
@@ -181,3 +300,41 @@ func paramInt64(c *fiber.Ctx, key string) (int64, error) {
 	}
 	return v, nil
 }
+
+// queryTime parses the query parameter as an RFC3339 timestamp.
+// If the query parameter is not set, the zero time is returned.
+func queryTime(c *fiber.Ctx, key string) (time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return t, apierror.Wrap(
+			err,
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("failed to parse query parameter %s %q as an RFC3339 timestamp", key, raw),
+			"routes.queryTime",
+		)
+	}
+	return t, nil
+}
+
+// queryInt parses the query parameter as an int.
+// If the query parameter is not set, fallback is returned.
+func queryInt(c *fiber.Ctx, key string, fallback int) (int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, apierror.Wrap(
+			err,
+			apierror.CodeInvalidParameter,
+			fmt.Sprintf("failed to parse query parameter %s %q", key, raw),
+			"routes.queryInt",
+		)
+	}
+	return v, nil
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/cszatmary/fridge-monitor/monitorit/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SensorTokenHandler struct {
+	fm  *models.FridgeManager
+	stm *models.SensorTokenManager
+}
+
+func NewSensorTokenHandler(fm *models.FridgeManager, stm *models.SensorTokenManager) *SensorTokenHandler {
+	return &SensorTokenHandler{fm, stm}
+}
+
+type sensorTokenResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+	// Token is only set when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+func newSensorTokenResponse(st models.SensorToken) sensorTokenResponse {
+	return sensorTokenResponse{
+		ID:        strconv.FormatInt(st.ID, 10),
+		Name:      st.Name,
+		CreatedAt: st.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (sth *SensorTokenHandler) List(ctx context.Context, c *fiber.Ctx) (any, error) {
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sth.fm.FindOneByID(ctx, fridgeID); err != nil {
+		return nil, err
+	}
+	tokens, err := sth.stm.FindAllByFridgeID(ctx, fridgeID)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		Tokens []sensorTokenResponse `json:"tokens"`
+	}{Tokens: make([]sensorTokenResponse, len(tokens))}
+	for i, st := range tokens {
+		body.Tokens[i] = newSensorTokenResponse(st)
+	}
+	return body, nil
+}
+
+// Create creates a new sensor token for the fridge. The response is the only
+// time the token is available, it cannot be retrieved again.
+func (sth *SensorTokenHandler) Create(ctx context.Context, c *fiber.Ctx) (any, error) {
+	const op = apierror.Op("routes.SensorTokenHandler.Create")
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	var reqBody struct {
+		Name string `json:"name"`
+	}
+	if err := parseBody(c, &reqBody); err != nil {
+		return nil, err
+	}
+	var v validator
+	v.checkName(reqBody.Name, "name")
+	if err := v.err(op); err != nil {
+		return nil, err
+	}
+	if _, err := sth.fm.FindOneByID(ctx, fridgeID); err != nil {
+		return nil, err
+	}
+	st, token, err := sth.stm.InsertOne(ctx, fridgeID, reqBody.Name)
+	if err != nil {
+		return nil, err
+	}
+	resp := newSensorTokenResponse(st)
+	resp.Token = token
+	return resp, nil
+}
+
+func (sth *SensorTokenHandler) Delete(ctx context.Context, c *fiber.Ctx) (any, error) {
+	fridgeID, err := paramInt64(c, "fridgeID")
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := paramInt64(c, "tokenID")
+	if err != nil {
+		return nil, err
+	}
+	if err := sth.stm.DeleteOne(ctx, fridgeID, tokenID); err != nil {
+		return nil, err
+	}
+	return struct {
+		ID string `json:"id"`
+	}{ID: strconv.FormatInt(tokenID, 10)}, nil
+}
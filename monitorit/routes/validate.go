@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cszatmary/fridge-monitor/monitorit/lib/apierror"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits on the values that are accepted for temperatures. Anything outside of them
+// can't be a real reading and most likely means the sensor is broken.
+const (
+	// minTemperature and maxTemperature cover the range of every supported sensor with some margin.
+	minTemperature = -100.0
+	maxTemperature = 150.0
+	// minPressure and maxPressure are in hPa and cover the range of the BME280 and BMP280.
+	minPressure = 300.0
+	maxPressure = 1100.0
+	// maxNameLen is the max length of names, ex: the name of a fridge or contact.
+	maxNameLen = 100
+)
+
+// validator collects problems with the fields of a request so they can all be reported at once
+// instead of making the client fix them one at a time.
+type validator struct {
+	fields []apierror.FieldError
+}
+
+// check records a problem with field if ok is false.
+func (v *validator) check(ok bool, field, format string, args ...any) {
+	if !ok {
+		v.fields = append(v.fields, apierror.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// checkName checks that a required name is provided and isn't too long.
+func (v *validator) checkName(name, field string) {
+	v.check(strings.TrimSpace(name) != "", field, "must not be empty")
+	v.check(len(name) <= maxNameLen, field, "must be at most %d characters", maxNameLen)
+}
+
+// checkTemperature checks that value is a temperature that could be read by a sensor.
+func (v *validator) checkTemperature(value float64, field string) {
+	v.check(value >= minTemperature && value <= maxTemperature, field, "must be between %g and %g", minTemperature, maxTemperature)
+}
+
+// checkHumidity checks that value is a relative humidity.
+func (v *validator) checkHumidity(value float64, field string) {
+	v.check(value >= 0 && value <= 100, field, "must be between 0 and 100")
+}
+
+// checkPressure checks that value is a pressure in hPa that could be read by a sensor.
+func (v *validator) checkPressure(value *float64, field string) {
+	if value != nil {
+		v.check(*value >= minPressure && *value <= maxPressure, field, "must be between %g and %g", minPressure, maxPressure)
+	}
+}
+
+// err returns a validation error with all the problems found, or nil if there were none.
+func (v *validator) err(op apierror.Op) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return apierror.NewValidation(v.fields, op)
+}
+
+// parseBody parses the request body into out. Unlike c.BodyParser, unknown fields in a JSON body are
+// rejected so that a typo in a field name isn't silently ignored.
+func parseBody(c *fiber.Ctx, out any) error {
+	const op = apierror.Op("routes.parseBody")
+	if !strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		if err := c.BodyParser(out); err != nil {
+			return apierror.Wrap(err, apierror.CodeInvalidParameter, "failed to parse request body", op)
+		}
+		if isForm(c) {
+			clearEmptyFormFields(c, out)
+		}
+		return nil
+	}
+
+	// encoding/json matches field names case insensitively which would let a typo like maxtemp
+	// through, so check the names first. This also reports every unknown field at once.
+	var raw any
+	if err := json.Unmarshal(c.Body(), &raw); err != nil {
+		return apierror.Wrap(err, apierror.CodeInvalidParameter, "failed to parse request body as JSON", op)
+	}
+	var v validator
+	checkKnownFields(&v, raw, reflect.TypeOf(out), "")
+	if err := v.err(op); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(c.Body()))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(out)
+	if err == nil {
+		return nil
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apierror.NewValidation([]apierror.FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		}}, op)
+	}
+	return apierror.Wrap(err, apierror.CodeInvalidParameter, "failed to parse request body as JSON", op)
+}
+
+// jsonTypeName returns the name of the JSON type that is decoded into t.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// checkKnownFields checks that every key of the JSON objects in raw exactly matches the name of a field in t.
+func checkKnownFields(v *validator, raw any, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch raw := raw.(type) {
+	case map[string]any:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields[name] = f.Type
+		}
+		// Sort the keys so errors are always in the same order
+		keys := make([]string, 0, len(raw))
+		for key := range raw {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			ft, ok := fields[key]
+			v.check(ok, field, "is not a known field")
+			if ok {
+				checkKnownFields(v, raw[key], ft, field)
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, value := range raw {
+			checkKnownFields(v, value, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// clearEmptyFormFields sets the pointer fields of out to nil if their input was left empty in the submitted form.
+// c.BodyParser sets them to the zero value instead, which would make an empty input impossible to tell apart from 0.
+func clearEmptyFormFields(c *fiber.Ctx, out any) {
+	v := reflect.ValueOf(out).Elem()
+	t := v.Type()
+	args := c.Request().PostArgs()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("form")
+		if f.Type.Kind() == reflect.Pointer && name != "" && args.Has(name) && len(args.Peek(name)) == 0 {
+			v.Field(i).Set(reflect.Zero(f.Type))
+		}
+	}
+}
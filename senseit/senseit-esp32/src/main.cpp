@@ -10,6 +10,7 @@
 
 #define UPDATE_INTERVAL_MS 30000 // 30sec
 #define POST_INTERVAL_MS 600000  // 10min
+#define FIRMWARE_VERSION "senseit-esp32/1.0.0"
 
 unsigned long lastTimeUpdate = 0;
 unsigned long lastTimePost = 0;
@@ -117,15 +118,20 @@ void loop() {
     HTTPClient http;
     http.begin(wifiClient, MONITORIT_URL);
     http.addHeader("Content-Type", "application/json");
+    http.addHeader("Authorization", "Bearer " MONITORIT_API_TOKEN);
+    // Identify this device so MonitorIt knows which sensor sent the temperature.
+    http.addHeader("X-Device-Serial", WiFi.macAddress());
+    http.addHeader("X-Device-Firmware", FIRMWARE_VERSION);
     // This is a little hacky but the JSON body is so simple that it seems
     // overkill to add a JSON library as a dependency just for this.
     // This works well enough.
     String requestData;
-    requestData.reserve(40);
+    requestData.reserve(80);
     requestData += "{\"value\":";
     requestData += String(temp.value, 2);
     requestData += ",\"humidity\":";
     requestData += String(temp.humidity, 2);
+    requestData += ",\"channel\":\"" MONITORIT_CHANNEL "\"";
     requestData += "}";
     int status = http.POST(requestData);
     if (status > 0) {
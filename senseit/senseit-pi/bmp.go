@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/d2r2/go-bsbmp"
+	"github.com/d2r2/go-i2c"
+	loggerpkg "github.com/d2r2/go-logger"
+)
+
+// bmpKind is a sensor from the Bosch BMP family that is supported.
+type bmpKind struct {
+	sensorType bsbmp.SensorType
+	// hasHumidity is whether the sensor can measure humidity.
+	hasHumidity bool
+}
+
+var (
+	bmpKindBME280 = bmpKind{bsbmp.BME280, true}
+	bmpKindBMP280 = bmpKind{bsbmp.BMP280, false}
+)
+
+// bmpSensor is a BME280 or BMP280 sensor connected over I2C.
+type bmpSensor struct {
+	kind bmpKind
+	i2c  *i2c.I2C
+	bmp  *bsbmp.BMP
+}
+
+// newBMPSensor connects to the sensor with the given address on the I2C bus.
+func newBMPSensor(kind bmpKind, address uint8, bus int) (*bmpSensor, error) {
+	conn, err := i2c.NewI2C(address, bus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create i2c connection to sensor: %w", err)
+	}
+	// Supress verbose output from i2c package.
+	if err := loggerpkg.ChangePackageLogLevel("i2c", loggerpkg.InfoLevel); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set log level for i2c package: %w", err)
+	}
+
+	bmp, err := bsbmp.NewBMP(kind.sensorType, conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialize sensor: %w", err)
+	}
+	// Suppress verbose output from the bsbmp package.
+	if err := loggerpkg.ChangePackageLogLevel("bsbmp", loggerpkg.InfoLevel); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set log level for bsbmp package: %w", err)
+	}
+	return &bmpSensor{kind: kind, i2c: conn, bmp: bmp}, nil
+}
+
+func (s *bmpSensor) Read() (measurement, error) {
+	temp, err := s.bmp.ReadTemperatureC(bsbmp.ACCURACY_STANDARD)
+	if err != nil {
+		return measurement{}, fmt.Errorf("failed to read temperature from sensor: %w", err)
+	}
+	pressure, err := s.bmp.ReadPressurePa(bsbmp.ACCURACY_STANDARD)
+	if err != nil {
+		return measurement{}, fmt.Errorf("failed to read pressure from sensor: %w", err)
+	}
+	// monitorit expects pressure in hPa
+	pressure /= 100
+	m := measurement{Temperature: temp, Pressure: &pressure}
+	if s.kind.hasHumidity {
+		_, humidity, err := s.bmp.ReadHumidityRH(bsbmp.ACCURACY_STANDARD)
+		if err != nil {
+			return measurement{}, fmt.Errorf("failed to read humidity from sensor: %w", err)
+		}
+		m.Humidity = &humidity
+	}
+	return m, nil
+}
+
+func (s *bmpSensor) Close() error {
+	return s.i2c.Close()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the configuration of senseit. It can be provided in a YAML config file and
+// overridden with flags.
+type config struct {
+	// URL is the temperatures URL of the fridge in monitorit, ex: http://localhost:8080/fridges/1/temperatures.
+	URL string `yaml:"url"`
+	// Token is a sensor token for the fridge created with POST /fridges/:fridgeID/tokens.
+	Token string `yaml:"token"`
+	// Timeout is the max time a request to monitorit can take.
+	Timeout time.Duration `yaml:"timeout"`
+	Retry   retryConfig   `yaml:"retry"`
+	// QueuePath is the file readings are stored in until they are sent to monitorit.
+	QueuePath string `yaml:"queuePath"`
+	// Daemon is whether to keep running and send a reading every interval instead of sending one and exiting.
+	Daemon   bool          `yaml:"daemon"`
+	Interval time.Duration `yaml:"interval"`
+	// Samples is the number of samples averaged for each reading.
+	Samples   int    `yaml:"samples"`
+	LogFormat string `yaml:"logFormat"`
+	// Sensors are all read during each run and their readings are sent together.
+	Sensors []sensorConfig `yaml:"sensors"`
+}
+
+// retryConfig is the policy for retrying requests to monitorit that fail.
+type retryConfig struct {
+	// Attempts is how many times sending a batch is attempted before giving up.
+	Attempts int `yaml:"attempts"`
+	// Backoff is how long to wait before retrying, it doubles after each attempt.
+	Backoff time.Duration `yaml:"backoff"`
+}
+
+func defaultConfig() config {
+	return config{
+		Token:   os.Getenv("MONITORIT_API_TOKEN"),
+		Timeout: 30 * time.Second,
+		Retry: retryConfig{
+			Attempts: 3,
+			Backoff:  2 * time.Second,
+		},
+		QueuePath: os.Getenv("SENSEIT_QUEUE_PATH"),
+		Interval:  time.Minute,
+		Samples:   1,
+		LogFormat: logFormatText,
+	}
+}
+
+// loadConfig reads the YAML config file at path. Any fields that are missing use their defaults.
+func loadConfig(path string) (config, error) {
+	cfg := defaultConfig()
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	// Catch typos instead of silently ignoring them
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Names of the flags that configure a single sensor.
+var sensorFlags = map[string]bool{
+	"sensor":           true,
+	"address":          true,
+	"i2c-bus":          true,
+	"channel":          true,
+	"sim-baseline":     true,
+	"sim-noise":        true,
+	"sim-drift":        true,
+	"sim-failure-rate": true,
+	"sim-seed":         true,
+}
+
+// newFlagSet creates flags that set the fields of cfg and sensor. The current values are used as the defaults.
+func newFlagSet(cfg *config, sensor *sensorConfig) *flag.FlagSet {
+	fs := flag.NewFlagSet("senseit", flag.ContinueOnError)
+	fs.String("config", os.Getenv("SENSEIT_CONFIG"), "Path to a YAML config file. Flags override values in the file.")
+	fs.StringVar(&cfg.URL, "url", cfg.URL, "Temperatures URL of the fridge in monitorit, ex: http://localhost:8080/fridges/1/temperatures.")
+	fs.StringVar(&cfg.Token, "token", cfg.Token, "Sensor token for the fridge. Defaults to $MONITORIT_API_TOKEN.")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "Max time a request to monitorit can take.")
+	fs.IntVar(&cfg.Retry.Attempts, "retry-attempts", cfg.Retry.Attempts, "How many times sending readings is attempted before giving up.")
+	fs.DurationVar(&cfg.Retry.Backoff, "retry-backoff", cfg.Retry.Backoff, "How long to wait before retrying, doubles after each attempt.")
+	fs.StringVar(&cfg.QueuePath, "queue-path", cfg.QueuePath, "File to store readings in until they are sent. Defaults to $SENSEIT_QUEUE_PATH or the user cache dir.")
+	fs.BoolVar(&cfg.Daemon, "daemon", cfg.Daemon, "Keep running and send a reading every interval instead of sending one and exiting.")
+	fs.DurationVar(&cfg.Interval, "interval", cfg.Interval, "How often to send a reading in daemon mode.")
+	fs.IntVar(&cfg.Samples, "samples", cfg.Samples, "Number of samples to average for each reading. In daemon mode they are spread evenly over the interval.")
+	fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Format of logs, either text or systemd.")
+
+	fs.StringVar(&sensor.Type, "sensor", sensor.Type, "Type of sensor, one of "+strings.Join(sensorTypes, ", ")+".")
+	fs.StringVar(&sensor.Address, "address", sensor.Address, "Address of the sensor. The I2C address, ex: 0x76, or the 1-Wire device ID for ds18b20.")
+	fs.IntVar(&sensor.I2CBus, "i2c-bus", sensor.I2CBus, "Number of the I2C bus the sensor is connected to.")
+	fs.StringVar(&sensor.Channel, "channel", sensor.Channel, "Channel of the sensor if the fridge has multiple sensors. Defaults to $MONITORIT_CHANNEL.")
+	simCfg := &sensor.Simulated
+	fs.Float64Var(&simCfg.Baseline, "sim-baseline", simCfg.Baseline, "Starting temperature in °C of the simulated sensor.")
+	fs.Float64Var(&simCfg.Noise, "sim-noise", simCfg.Noise, "Standard deviation of the noise added to each reading of the simulated sensor.")
+	fs.Float64Var(&simCfg.Drift, "sim-drift", simCfg.Drift, "How much the temperature of the simulated sensor changes per hour in °C.")
+	fs.Float64Var(&simCfg.FailureRate, "sim-failure-rate", simCfg.FailureRate, "Probability between 0 and 1 that a read of the simulated sensor fails.")
+	fs.Int64Var(&simCfg.Seed, "sim-seed", simCfg.Seed, "Seed for the random noise of the simulated sensor. If 0, a random seed is used.")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: senseit [flags]\n")
+		fmt.Fprintf(out, "       senseit -config <config file> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseConfig builds the config from the config file, if one is provided, and the flags in args.
+func parseConfig(args []string) (config, error) {
+	// Parse the flags once to find the config file. The flags are then parsed again so
+	// they override the values in the config file.
+	scratch, scratchSensor := defaultConfig(), defaultSensorConfig()
+	fs := newFlagSet(&scratch, &scratchSensor)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	cfg := defaultConfig()
+	if path := fs.Lookup("config").Value.String(); path != "" {
+		var err error
+		if cfg, err = loadConfig(path); err != nil {
+			return cfg, err
+		}
+	}
+
+	// The sensor flags change the sensor in the config file, or add one if there isn't one
+	sensor := defaultSensorConfig()
+	if len(cfg.Sensors) == 1 {
+		sensor = cfg.Sensors[0]
+	}
+	fs = newFlagSet(&cfg, &sensor)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	setSensorFlag := false
+	fs.Visit(func(f *flag.Flag) {
+		if sensorFlags[f.Name] {
+			setSensorFlag = true
+		}
+	})
+	if len(cfg.Sensors) > 1 && setSensorFlag {
+		return cfg, errors.New("sensor flags can't be used when the config file has multiple sensors")
+	}
+	if len(cfg.Sensors) <= 1 {
+		cfg.Sensors = []sensorConfig{sensor}
+	}
+
+	// Support the positional arguments from before there were flags: [sensor address] <monitorit temperatures URL>
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		cfg.URL = fs.Arg(0)
+	case 2:
+		if len(cfg.Sensors) > 1 {
+			return cfg, errors.New("sensor address argument can't be used when the config file has multiple sensors")
+		}
+		cfg.Sensors[0].Address = fs.Arg(0)
+		cfg.URL = fs.Arg(1)
+	default:
+		return cfg, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if cfg.QueuePath == "" {
+		cfg.QueuePath = defaultQueuePath()
+	}
+	for i := range cfg.Sensors {
+		sim := &cfg.Sensors[i].Simulated
+		if sim.Seed == 0 {
+			// Add the index so multiple simulated sensors don't produce the same readings
+			sim.Seed = time.Now().UnixNano() + int64(i)
+		}
+	}
+	return cfg, cfg.validate()
+}
+
+// validate checks that cfg is valid.
+func (cfg config) validate() error {
+	switch {
+	case cfg.URL == "":
+		return errors.New("monitorit URL is required, set it with -url or in the config file")
+	case cfg.Timeout <= 0:
+		return fmt.Errorf("timeout must be positive, got %s", cfg.Timeout)
+	case cfg.Retry.Attempts < 1:
+		return fmt.Errorf("retry attempts must be at least 1, got %d", cfg.Retry.Attempts)
+	case cfg.Retry.Backoff < 0:
+		return fmt.Errorf("retry backoff must not be negative, got %s", cfg.Retry.Backoff)
+	case cfg.LogFormat != logFormatText && cfg.LogFormat != logFormatSystemd:
+		return fmt.Errorf("log format must be %s or %s, got %q", logFormatText, logFormatSystemd, cfg.LogFormat)
+	case cfg.Interval <= 0:
+		return fmt.Errorf("interval must be positive, got %s", cfg.Interval)
+	case cfg.Samples < 1:
+		return fmt.Errorf("samples must be at least 1, got %d", cfg.Samples)
+	}
+
+	channels := make(map[string]bool)
+	for i, sc := range cfg.Sensors {
+		switch {
+		case !validSensorType(sc.Type):
+			return fmt.Errorf("sensors[%d]: type must be one of %s, got %q", i, strings.Join(sensorTypes, ", "), sc.Type)
+		case sc.Address == "" && sc.Type != sensorTypeSimulated && sc.Type != sensorTypeDS18B20:
+			return fmt.Errorf("sensors[%d]: address is required for %s sensors", i, sc.Type)
+		case sc.Simulated.FailureRate < 0 || sc.Simulated.FailureRate > 1:
+			return fmt.Errorf("sensors[%d]: simulated failure rate must be between 0 and 1, got %g", i, sc.Simulated.FailureRate)
+		case channels[sc.Channel]:
+			// monitorit wouldn't be able to tell the readings apart
+			return fmt.Errorf("sensors[%d]: channel %q is used by another sensor, each sensor must have a different channel", i, sc.Channel)
+		}
+		channels[sc.Channel] = true
+	}
+	return nil
+}
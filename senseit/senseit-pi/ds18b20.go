@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// w1DevicesDir is where the Linux w1 subsystem exposes 1-Wire devices.
+// The w1-gpio and w1-therm kernel modules must be loaded, ex: with dtoverlay=w1-gpio on a Raspberry Pi.
+const w1DevicesDir = "/sys/bus/w1/devices"
+
+// ds18b20FamilyPrefix is the prefix of the device IDs of DS18B20 sensors.
+const ds18b20FamilyPrefix = "28-"
+
+// ds18b20Sensor is a DS18B20 1-Wire temperature probe read using the Linux sysfs w1 interface.
+type ds18b20Sensor struct {
+	// path is the path of the w1_slave file of the sensor.
+	path string
+}
+
+// newDS18B20Sensor finds the DS18B20 sensor with the given 1-Wire device ID, ex: 28-0316a2795aff.
+// If id is empty, the only DS18B20 connected is used.
+func newDS18B20Sensor(id string) (*ds18b20Sensor, error) {
+	if id == "" {
+		matches, err := filepath.Glob(filepath.Join(w1DevicesDir, ds18b20FamilyPrefix+"*"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find DS18B20 sensors: %w", err)
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no DS18B20 sensors found in %s, make sure the w1-gpio and w1-therm modules are loaded", w1DevicesDir)
+		case 1:
+			id = filepath.Base(matches[0])
+		default:
+			return nil, fmt.Errorf("found %d DS18B20 sensors, the device ID of the sensor must be provided as the address", len(matches))
+		}
+	}
+	s := &ds18b20Sensor{path: filepath.Join(w1DevicesDir, id, "w1_slave")}
+	if _, err := os.Stat(s.path); err != nil {
+		return nil, fmt.Errorf("failed to find DS18B20 sensor %s: %w", id, err)
+	}
+	return s, nil
+}
+
+func (s *ds18b20Sensor) Read() (measurement, error) {
+	// Reading the file triggers a conversion which takes up to 750ms. The contents look like:
+	//   72 01 4b 46 7f ff 0e 10 57 : crc=57 YES
+	//   72 01 4b 46 7f ff 0e 10 57 t=23125
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return measurement{}, fmt.Errorf("failed to read temperature from sensor: %w", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		return measurement{}, fmt.Errorf("unexpected output from sensor: %q", b)
+	}
+	if !strings.HasSuffix(lines[0], "YES") {
+		return measurement{}, errors.New("sensor CRC check failed, check the wiring of the sensor")
+	}
+	i := strings.LastIndex(lines[1], "t=")
+	if i == -1 {
+		return measurement{}, fmt.Errorf("temperature missing from sensor output: %q", lines[1])
+	}
+	milliC, err := strconv.Atoi(lines[1][i+len("t="):])
+	if err != nil {
+		return measurement{}, fmt.Errorf("failed to parse temperature from sensor output: %w", err)
+	}
+	return measurement{Temperature: float32(milliC) / 1000}, nil
+}
+
+func (s *ds18b20Sensor) Close() error {
+	return nil
+}
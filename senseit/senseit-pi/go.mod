@@ -6,6 +6,7 @@ require (
 	github.com/d2r2/go-bsbmp v0.0.0-20190515110334-3b4b3aea8375
 	github.com/d2r2/go-i2c v0.0.0-20191123181816-73a8a799d6bc
 	github.com/d2r2/go-logger v0.0.0-20210606094344-60e9d1233e22
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+const (
+	// logFormatText logs with timestamps, suitable for writing to a file from cron.
+	logFormatText = "text"
+	// logFormatSystemd logs without timestamps since journald adds them, and prefixes each
+	// line with its syslog priority so journald can tell the level of each message.
+	logFormatSystemd = "systemd"
+)
+
+// leveledLogger is a minimal logger that supports log levels.
+type leveledLogger struct {
+	l       *log.Logger
+	systemd bool
+}
+
+func newLogger(w io.Writer, format string) *leveledLogger {
+	if format == logFormatSystemd {
+		return &leveledLogger{l: log.New(w, "", 0), systemd: true}
+	}
+	return &leveledLogger{l: log.New(w, "", log.LstdFlags)}
+}
+
+func (ll *leveledLogger) Infof(format string, a ...any) {
+	ll.output("<6>", "", format, a...)
+}
+
+func (ll *leveledLogger) Warnf(format string, a ...any) {
+	ll.output("<4>", "Warning: ", format, a...)
+}
+
+func (ll *leveledLogger) Errorf(format string, a ...any) {
+	ll.output("<3>", "Error: ", format, a...)
+}
+
+func (ll *leveledLogger) output(priority, prefix, format string, a ...any) {
+	if ll.systemd {
+		prefix = priority
+	}
+	ll.l.Print(prefix + fmt.Sprintf(format, a...))
+}
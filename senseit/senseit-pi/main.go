@@ -1,80 +1,240 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
-
-	"github.com/d2r2/go-bsbmp"
-	"github.com/d2r2/go-i2c"
-	loggerpkg "github.com/d2r2/go-logger"
 )
 
+// Set during build with -ldflags "-X main.version=..."
+var version = "dev"
+
+// logger is used for all output. It is replaced once the log format flag is parsed.
+var logger = newLogger(os.Stderr, logFormatText)
+
+// oneShotSampleDelay is the time between samples when not running as a daemon.
+const oneShotSampleDelay = time.Second
+
 func main() {
 	if err := execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		logger.Errorf("%v", err)
 		os.Exit(1)
 	}
 }
 
 func execute() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("sensor address and monitorit post URL required as arguments")
+	cfg, err := parseConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
 	}
+	logger = newLogger(os.Stderr, cfg.LogFormat)
 
-	// Parse the address as an uint, usually in hex
-	sensorAddress, err := strconv.ParseUint(os.Args[1], 0, 8)
-	if err != nil {
-		return fmt.Errorf("failed to parse sensor address as a uint: %w", err)
+	s := &senseit{
+		queue:   queue{path: cfg.QueuePath},
+		client:  newMonitoritClient(cfg),
+		samples: cfg.Samples,
+	}
+	for _, sc := range cfg.Sensors {
+		sensor, err := newSensor(sc)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", sc.name(), err)
+		}
+		defer sensor.Close()
+		s.sensors = append(s.sensors, namedSensor{Sensor: sensor, cfg: sc})
 	}
-	monitoritURL := os.Args[2]
 
-	// Create new connection to i2c-bus on 1 line with the given address.
-	i2c, err := i2c.NewI2C(uint8(sensorAddress), 1)
-	if err != nil {
-		return fmt.Errorf("failed to create i2c connection to sensor: %w", err)
+	// Stop gracefully on SIGTERM (ex: from systemd) or SIGINT. Readings are always
+	// saved to the queue before being sent so nothing is lost if we are interrupted.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if !cfg.Daemon {
+		s.sampleDelay = oneShotSampleDelay
+		return s.run(ctx)
 	}
-	defer i2c.Close()
-	// Supress verbose output from i2c package.
-	if err := loggerpkg.ChangePackageLogLevel("i2c", loggerpkg.InfoLevel); err != nil {
-		return fmt.Errorf("failed to set log level for i2c package: %w", err)
+
+	s.sampleDelay = cfg.Interval / time.Duration(cfg.Samples)
+	logger.Infof("Starting senseit %s with %d sensors, sending a reading every %s averaged over %d samples", version, len(s.sensors), cfg.Interval, cfg.Samples)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		// Don't exit on errors, the next run may succeed. Anything that wasn't sent is still in the queue.
+		if err := s.run(ctx); err != nil && ctx.Err() == nil {
+			logger.Errorf("%v", err)
+		}
+		select {
+		case <-ctx.Done():
+			logger.Infof("Received signal, shutting down")
+			return nil
+		case <-ticker.C:
+		}
 	}
+}
 
-	sensor, err := bsbmp.NewBMP(bsbmp.BME280, i2c)
-	if err != nil {
-		return fmt.Errorf("failed to initialize sensor: %w", err)
+// senseit reads temperatures from the sensors and sends them to monitorit.
+type senseit struct {
+	sensors []namedSensor
+	queue   queue
+	client  *monitoritClient
+	// samples is the number of samples averaged for each reading.
+	samples int
+	// sampleDelay is the time between each sample.
+	sampleDelay time.Duration
+}
+
+// namedSensor is a sensor along with the config it was created from.
+type namedSensor struct {
+	Sensor
+	cfg sensorConfig
+}
+
+// run takes a reading from each sensor and sends them to monitorit along with any queued readings.
+func (s *senseit) run(ctx context.Context) error {
+	// Readings from sensors that worked are still sent if other sensors fail
+	readings, readErr := s.read(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-	// Suppress verbose output from the bsbmp package.
-	if err := loggerpkg.ChangePackageLogLevel("bsbmp", loggerpkg.InfoLevel); err != nil {
-		return fmt.Errorf("failed to set log level for bsbmp package: %w", err)
+
+	// Add the readings to the queue first so they aren't lost if they can't be sent.
+	// Then send everything in the queue which includes readings from previous runs that failed.
+	if len(readings) > 0 {
+		queued, err := s.queue.load()
+		if err != nil {
+			return err
+		}
+		if err := s.queue.save(append(queued, readings...)); err != nil {
+			return err
+		}
+	}
+	if err := s.client.sendQueued(ctx, s.queue); err != nil {
+		if readErr != nil {
+			logger.Errorf("%v", readErr)
+		}
+		return err
 	}
+	return readErr
+}
 
-	// Read the temperature and send it to monitorit
-	temp, err := sensor.ReadTemperatureC(bsbmp.ACCURACY_STANDARD)
-	if err != nil {
-		return fmt.Errorf("failed to read temperature from sensor: %w", err)
+// read takes samples from each sensor and returns a reading for each sensor with their average.
+// If a sample can't be read from a sensor, there is no reading for that sensor and an error is returned
+// along with the readings from the other sensors.
+func (s *senseit) read(ctx context.Context) ([]reading, error) {
+	sums := make([]measurementSum, len(s.sensors))
+	errs := make([]error, len(s.sensors))
+	for i := 0; i < s.samples; i++ {
+		if i > 0 {
+			if err := sleep(ctx, s.sampleDelay); err != nil {
+				return nil, err
+			}
+		}
+		for j, sensor := range s.sensors {
+			if errs[j] != nil {
+				continue
+			}
+			m, err := sensor.Read()
+			if err != nil {
+				errs[j] = fmt.Errorf("failed to read %s: %w", sensor.cfg.name(), err)
+				continue
+			}
+			sums[j].add(m)
+		}
 	}
 
-	body := struct {
-		Value float32 `json:"value"`
-	}{Value: temp}
-	var bodyBuf bytes.Buffer
-	if err := json.NewEncoder(&bodyBuf).Encode(body); err != nil {
-		return fmt.Errorf("failed to encode request body as JSON: %w", err)
+	var readings []reading
+	var failed []error
+	now := time.Now().UTC()
+	for j, sensor := range s.sensors {
+		if errs[j] != nil {
+			failed = append(failed, errs[j])
+			continue
+		}
+		r := sums[j].average()
+		r.Channel = sensor.cfg.Channel
+		r.CreatedAt = now
+		readings = append(readings, r)
 	}
+	switch len(failed) {
+	case 0:
+		return readings, nil
+	case 1:
+		return readings, failed[0]
+	}
+	for _, err := range failed {
+		logger.Errorf("%v", err)
+	}
+	return readings, fmt.Errorf("failed to read %d of %d sensors", len(failed), len(s.sensors))
+}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
-	resp, err := httpClient.Post(monitoritURL, "application/json", &bodyBuf)
-	if err != nil {
-		return fmt.Errorf("failed to send POST request to monitorit: %w", err)
+// measurementSum adds up the measurements from a sensor so they can be averaged.
+type measurementSum struct {
+	temp, humidity, pressure float32
+	count                    int
+	humidityCount            int
+	pressureCount            int
+}
+
+func (ms *measurementSum) add(m measurement) {
+	ms.temp += m.Temperature
+	ms.count++
+	if m.Humidity != nil {
+		ms.humidity += *m.Humidity
+		ms.humidityCount++
+	}
+	if m.Pressure != nil {
+		ms.pressure += *m.Pressure
+		ms.pressureCount++
+	}
+}
+
+// average returns a reading with the average of the measurements.
+func (ms *measurementSum) average() reading {
+	r := reading{Value: ms.temp / float32(ms.count)}
+	if ms.humidityCount > 0 {
+		humidity := ms.humidity / float32(ms.humidityCount)
+		r.Humidity = &humidity
+	}
+	if ms.pressureCount > 0 {
+		pressure := ms.pressure / float32(ms.pressureCount)
+		r.Pressure = &pressure
+	}
+	return r
+}
+
+// sleep pauses for d or until ctx is cancelled, in which case the error of ctx is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("received status %d from monitorit", resp.StatusCode)
+}
+
+// deviceSerial returns the serial number of the Raspberry Pi from /proc/cpuinfo.
+// If it can't be read, the hostname is used instead.
+func deviceSerial() string {
+	if b, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(b), "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if ok && strings.TrimSpace(key) == "Serial" {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
 	}
-	return nil
+	return hostname
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMonitorit is a batch temperatures endpoint that records the readings it receives.
+type fakeMonitorit struct {
+	mu       sync.Mutex
+	received []reading
+	// status is the status to respond with, 0 means http.StatusOK.
+	status int
+}
+
+func (fm *fakeMonitorit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/fridges/1/temperatures/batch" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if fm.status != 0 {
+		w.WriteHeader(fm.status)
+		return
+	}
+	var body struct {
+		Temperatures []reading `json:"temperatures"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fm.received = append(fm.received, body.Temperatures...)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"temperatures":[],"rejected":[]}`))
+}
+
+func (fm *fakeMonitorit) readings() []reading {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return append([]reading(nil), fm.received...)
+}
+
+func (fm *fakeMonitorit) setStatus(status int) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.status = status
+}
+
+func newTestSenseit(t *testing.T, url string, sensors ...sensorConfig) *senseit {
+	t.Helper()
+	cfg := config{
+		URL:     url + "/fridges/1/temperatures",
+		Token:   "token",
+		Timeout: 5 * time.Second,
+		Retry:   retryConfig{Attempts: 1},
+	}
+	s := &senseit{
+		queue:   queue{path: filepath.Join(t.TempDir(), "queue.jsonl")},
+		client:  newMonitoritClient(cfg),
+		samples: 3,
+	}
+	for _, sc := range sensors {
+		sensor, err := newSensor(sc)
+		if err != nil {
+			t.Fatalf("failed to create sensor: %v", err)
+		}
+		t.Cleanup(func() { sensor.Close() })
+		s.sensors = append(s.sensors, namedSensor{Sensor: sensor, cfg: sc})
+	}
+	return s
+}
+
+func simulatedConfig(channel string) sensorConfig {
+	sc := defaultSensorConfig()
+	sc.Type = sensorTypeSimulated
+	sc.Channel = channel
+	sc.Simulated.Seed = 1
+	return sc
+}
+
+func TestRunSimulatedSensor(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	fm := &fakeMonitorit{}
+	srv := httptest.NewServer(fm)
+	defer srv.Close()
+	s := newTestSenseit(t, srv.URL, simulatedConfig("top"), simulatedConfig("bottom"))
+
+	if err := s.run(context.Background()); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	received := fm.readings()
+	if len(received) != 2 {
+		t.Fatalf("got %d readings, want 2", len(received))
+	}
+	for i, channel := range []string{"top", "bottom"} {
+		r := received[i]
+		if r.Channel != channel {
+			t.Errorf("reading %d: got channel %q, want %q", i, r.Channel, channel)
+		}
+		// Baseline is 4 with a noise of 0.1 so the average of the samples must be close to it
+		if r.Value < 3.5 || r.Value > 4.5 {
+			t.Errorf("reading %d: got temperature %g, want about 4", i, r.Value)
+		}
+		if r.Humidity == nil || r.Pressure == nil {
+			t.Errorf("reading %d: want humidity and pressure to be set", i)
+		}
+		if time.Since(r.CreatedAt) > time.Minute {
+			t.Errorf("reading %d: got createdAt %s, want the time it was read", i, r.CreatedAt)
+		}
+	}
+	queued, err := s.queue.load()
+	if err != nil {
+		t.Fatalf("failed to load queue: %v", err)
+	}
+	if len(queued) != 0 {
+		t.Errorf("got %d queued readings after sending, want 0", len(queued))
+	}
+}
+
+func TestRunQueuesReadingsWhenMonitoritIsDown(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	fm := &fakeMonitorit{status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(fm)
+	defer srv.Close()
+	s := newTestSenseit(t, srv.URL, simulatedConfig(""))
+
+	if err := s.run(context.Background()); err == nil {
+		t.Fatal("want run to fail when monitorit is down")
+	}
+	queued, err := s.queue.load()
+	if err != nil {
+		t.Fatalf("failed to load queue: %v", err)
+	}
+	if len(queued) != 1 {
+		t.Fatalf("got %d queued readings, want 1", len(queued))
+	}
+
+	// The queued reading is sent along with the new one once monitorit is back
+	fm.setStatus(0)
+	if err := s.run(context.Background()); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	received := fm.readings()
+	if len(received) != 2 {
+		t.Fatalf("got %d readings, want 2", len(received))
+	}
+	if !received[0].CreatedAt.Equal(queued[0].CreatedAt) {
+		t.Errorf("got createdAt %s for the queued reading, want %s", received[0].CreatedAt, queued[0].CreatedAt)
+	}
+}
+
+func TestRunDropsRejectedReadings(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	fm := &fakeMonitorit{status: http.StatusUnprocessableEntity}
+	srv := httptest.NewServer(fm)
+	defer srv.Close()
+	s := newTestSenseit(t, srv.URL, simulatedConfig(""))
+
+	// Sending them again won't help so they are dropped instead of blocking the queue
+	if err := s.run(context.Background()); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	queued, err := s.queue.load()
+	if err != nil {
+		t.Fatalf("failed to load queue: %v", err)
+	}
+	if len(queued) != 0 {
+		t.Errorf("got %d queued readings, want 0", len(queued))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBatchSize is the max number of readings monitorit accepts in a single batch.
+const maxBatchSize = 1000
+
+// errRejected means monitorit rejected the whole batch because none of the readings were valid. Sending them again won't help.
+var errRejected = errors.New("readings rejected by monitorit")
+
+// statusError is returned when monitorit responds with an unexpected status.
+type statusError struct {
+	status int
+	body   []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("received status %d from monitorit: %s", e.status, e.body)
+}
+
+// temporary returns whether the request could succeed if it is retried.
+func (e *statusError) temporary() bool {
+	return e.status >= 500 || e.status == http.StatusTooManyRequests
+}
+
+// monitoritClient sends readings to monitorit.
+type monitoritClient struct {
+	httpClient *http.Client
+	// batchURL is the URL of the batch temperatures endpoint of the fridge.
+	batchURL string
+	token    string
+	retry    retryConfig
+}
+
+func newMonitoritClient(cfg config) *monitoritClient {
+	return &monitoritClient{
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		batchURL:   strings.TrimSuffix(cfg.URL, "/") + "/batch",
+		token:      cfg.Token,
+		retry:      cfg.Retry,
+	}
+}
+
+// sendQueued sends all the readings in the queue, oldest first. Readings that are sent are
+// removed from the queue. If sending fails, the readings that were not sent remain in the queue
+// so they can be sent on the next run.
+func (mc *monitoritClient) sendQueued(ctx context.Context, q queue) error {
+	readings, err := q.load()
+	if err != nil {
+		return err
+	}
+	for len(readings) > 0 {
+		n := len(readings)
+		if n > maxBatchSize {
+			n = maxBatchSize
+		}
+		err := mc.sendWithRetry(ctx, readings[:n])
+		if errors.Is(err, errRejected) {
+			// Drop them, otherwise the queue would be stuck forever
+			logger.Warnf("Dropping %d readings: %v", n, err)
+		} else if err != nil {
+			if saveErr := q.save(readings); saveErr != nil {
+				logger.Errorf("%v", saveErr)
+			}
+			return fmt.Errorf("failed to send %d queued readings: %w", len(readings), err)
+		}
+		readings = readings[n:]
+		logger.Infof("Sent %d readings to monitorit", n)
+	}
+	return q.save(nil)
+}
+
+// sendWithRetry sends the readings, retrying with exponential backoff if the error is temporary,
+// i.e. a network error or a server error.
+func (mc *monitoritClient) sendWithRetry(ctx context.Context, readings []reading) error {
+	backoff := mc.retry.Backoff
+	var err error
+	for attempt := 1; attempt <= mc.retry.Attempts; attempt++ {
+		err = mc.send(ctx, readings)
+		var statusErr *statusError
+		if err == nil || errors.Is(err, errRejected) || (errors.As(err, &statusErr) && !statusErr.temporary()) {
+			return err
+		}
+		if attempt < mc.retry.Attempts {
+			logger.Warnf("Attempt %d to send readings failed, retrying in %s: %v", attempt, backoff, err)
+			if err := sleep(ctx, backoff); err != nil {
+				return err
+			}
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// send sends the readings to monitorit in a single batch.
+func (mc *monitoritClient) send(ctx context.Context, readings []reading) error {
+	body := struct {
+		Temperatures []reading `json:"temperatures"`
+	}{Temperatures: readings}
+	var bodyBuf bytes.Buffer
+	if err := json.NewEncoder(&bodyBuf).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode request body as JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mc.batchURL, &bodyBuf)
+	if err != nil {
+		return fmt.Errorf("failed to create POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Sensor token created with POST /fridges/:fridgeID/tokens
+	if mc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+mc.token)
+	}
+	// Identify this device so monitorit knows which sensor sent the temperature
+	req.Header.Set("X-Device-Serial", deviceSerial())
+	req.Header.Set("X-Device-Firmware", "senseit-pi/"+version)
+
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send POST request to monitorit: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		// Invalid readings are skipped by monitorit instead of failing the batch, log them so a broken sensor is noticed
+		var respBody struct {
+			Rejected []struct {
+				Fields []struct {
+					Field   string `json:"field"`
+					Message string `json:"message"`
+				} `json:"fields"`
+			} `json:"rejected"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+			logger.Warnf("Failed to decode response from monitorit: %v", err)
+			return nil
+		}
+		for _, r := range respBody.Rejected {
+			for _, f := range r.Fields {
+				logger.Warnf("Dropping reading rejected by monitorit: %s: %s", f.Field, f.Message)
+			}
+		}
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity {
+		return fmt.Errorf("%w: %s", errRejected, respBody)
+	}
+	return &statusError{status: resp.StatusCode, body: respBody}
+}
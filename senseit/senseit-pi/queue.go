@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// maxQueueLen is the max number of readings kept in the queue.
+	// If there are more, the oldest are dropped.
+	maxQueueLen = 10000
+	// maxReadingAge is how old a reading can be before it is dropped from the queue.
+	// monitorit rejects readings older than 7 days so leave some margin.
+	maxReadingAge = 7*24*time.Hour - time.Hour
+)
+
+// reading is a temperature read from the sensor.
+type reading struct {
+	Value float32 `json:"value"`
+	// Humidity is the relative humidity in %. It is nil if the sensor can't measure humidity.
+	Humidity *float32 `json:"humidity,omitempty"`
+	// Pressure is in hPa. It is nil if the sensor can't measure pressure.
+	Pressure *float32 `json:"pressure,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	// CreatedAt is when the reading was captured. It is sent to monitorit so readings
+	// that are replayed from the queue have the correct time.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// queue stores readings on disk that have not been sent to monitorit yet.
+// Each reading is stored as a line of JSON in the file at path.
+type queue struct {
+	path string
+}
+
+// defaultQueuePath returns the path of the queue file if one was not provided.
+func defaultQueuePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "senseit", "queue.jsonl")
+}
+
+// load returns the readings in the queue, oldest first. Readings that are too old to be sent are dropped.
+func (q queue) load() ([]reading, error) {
+	b, err := os.ReadFile(q.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read queue file %s: %w", q.path, err)
+	}
+
+	var readings []reading
+	cutoff := time.Now().Add(-maxReadingAge)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var r reading
+		if err := json.Unmarshal(line, &r); err != nil {
+			// Most likely a partial write from losing power, skip it instead of getting stuck
+			logger.Warnf("Skipping invalid reading in queue file: %v", err)
+			continue
+		}
+		if r.CreatedAt.Before(cutoff) {
+			continue
+		}
+		readings = append(readings, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse queue file %s: %w", q.path, err)
+	}
+	return readings, nil
+}
+
+// save replaces the contents of the queue with readings. If there are too many readings
+// the oldest are dropped. The file is replaced atomically so it is never left half written.
+func (q queue) save(readings []reading) error {
+	if len(readings) > maxQueueLen {
+		logger.Warnf("Queue is full, dropping %d oldest readings", len(readings)-maxQueueLen)
+		readings = readings[len(readings)-maxQueueLen:]
+	}
+	if len(readings) == 0 {
+		if err := os.Remove(q.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove queue file %s: %w", q.path, err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range readings {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode reading: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	tmpPath := q.path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write queue file %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace queue file %s: %w", q.path, err)
+	}
+	return nil
+}
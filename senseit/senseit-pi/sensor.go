@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// measurement is a single sample from a sensor.
+type measurement struct {
+	// Temperature is in °C.
+	Temperature float32
+	// Humidity is the relative humidity in %. It is nil if the sensor can't measure humidity.
+	Humidity *float32
+	// Pressure is in hPa. It is nil if the sensor can't measure pressure.
+	Pressure *float32
+}
+
+// Sensor is a device that can measure the conditions in a fridge.
+type Sensor interface {
+	Read() (measurement, error)
+	Close() error
+}
+
+// Types of sensors that can be used.
+const (
+	sensorTypeBME280    = "bme280"
+	sensorTypeBMP280    = "bmp280"
+	sensorTypeSHT3x     = "sht3x"
+	sensorTypeDS18B20   = "ds18b20"
+	sensorTypeSimulated = "simulated"
+)
+
+var sensorTypes = []string{sensorTypeBME280, sensorTypeBMP280, sensorTypeSHT3x, sensorTypeDS18B20, sensorTypeSimulated}
+
+func validSensorType(sensorType string) bool {
+	for _, t := range sensorTypes {
+		if t == sensorType {
+			return true
+		}
+	}
+	return false
+}
+
+// sensorConfig specifies how to connect to a sensor.
+type sensorConfig struct {
+	Type string `yaml:"type"`
+	// Address identifies the sensor. For I2C sensors it is the address on the bus, usually in hex.
+	// For DS18B20 sensors it is the 1-Wire device ID, or empty to use the only one connected.
+	// It is unused for simulated sensors.
+	Address string `yaml:"address"`
+	// I2CBus is the number of the I2C bus the sensor is connected to.
+	I2CBus int `yaml:"bus"`
+	// Channel is sent with each reading from the sensor so monitorit can tell sensors
+	// in the same fridge apart, ex: one per shelf.
+	Channel string `yaml:"channel"`
+	// Simulated configures simulated sensors.
+	Simulated simulatedSensorConfig `yaml:"simulated"`
+}
+
+func defaultSensorConfig() sensorConfig {
+	return sensorConfig{
+		Type:    sensorTypeBME280,
+		I2CBus:  1,
+		Channel: os.Getenv("MONITORIT_CHANNEL"),
+		Simulated: simulatedSensorConfig{
+			Baseline: 4,
+			Noise:    0.1,
+		},
+	}
+}
+
+// UnmarshalYAML fills in any fields of the sensor that are missing from the config file with their defaults.
+func (sc *sensorConfig) UnmarshalYAML(value *yaml.Node) error {
+	// Use a different type so this method isn't called recursively
+	type plain sensorConfig
+	p := plain(defaultSensorConfig())
+	if err := value.Decode(&p); err != nil {
+		return err
+	}
+	*sc = sensorConfig(p)
+	return nil
+}
+
+// name returns a name for the sensor that can be used in logs.
+func (sc sensorConfig) name() string {
+	if sc.Channel != "" {
+		return fmt.Sprintf("%s sensor (channel %s)", sc.Type, sc.Channel)
+	}
+	return sc.Type + " sensor"
+}
+
+// newSensor connects to the sensor described by cfg.
+func newSensor(cfg sensorConfig) (Sensor, error) {
+	switch cfg.Type {
+	case sensorTypeSimulated:
+		return newSimulatedSensor(cfg.Simulated), nil
+	case sensorTypeDS18B20:
+		return newDS18B20Sensor(cfg.Address)
+	}
+
+	address, err := parseI2CAddress(cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Type {
+	case sensorTypeBME280:
+		return newBMPSensor(bmpKindBME280, address, cfg.I2CBus)
+	case sensorTypeBMP280:
+		return newBMPSensor(bmpKindBMP280, address, cfg.I2CBus)
+	case sensorTypeSHT3x:
+		return newSHT3xSensor(address, cfg.I2CBus)
+	default:
+		return nil, fmt.Errorf("unknown sensor type %q, must be one of %s", cfg.Type, strings.Join(sensorTypes, ", "))
+	}
+}
+
+// parseI2CAddress parses the address of an I2C device. It is usually in hex, ex: 0x76.
+func parseI2CAddress(s string) (uint8, error) {
+	address, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse sensor address as a uint: %w", err)
+	}
+	return uint8(address), nil
+}
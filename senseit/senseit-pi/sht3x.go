@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/d2r2/go-i2c"
+	loggerpkg "github.com/d2r2/go-logger"
+)
+
+const (
+	// sht3xMeasureCmd triggers a single shot measurement with high repeatability
+	// and clock stretching disabled.
+	sht3xMeasureCmd = 0x2400
+	// sht3xMeasureDuration is how long a high repeatability measurement takes.
+	// The max according to the datasheet is 15.5ms.
+	sht3xMeasureDuration = 20 * time.Millisecond
+)
+
+// sht3xSensor is a Sensirion SHT30, SHT31, or SHT35 sensor connected over I2C.
+// It measures temperature and humidity.
+type sht3xSensor struct {
+	i2c *i2c.I2C
+}
+
+// newSHT3xSensor connects to the sensor with the given address on the I2C bus.
+func newSHT3xSensor(address uint8, bus int) (*sht3xSensor, error) {
+	conn, err := i2c.NewI2C(address, bus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create i2c connection to sensor: %w", err)
+	}
+	// Supress verbose output from i2c package.
+	if err := loggerpkg.ChangePackageLogLevel("i2c", loggerpkg.InfoLevel); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set log level for i2c package: %w", err)
+	}
+	return &sht3xSensor{i2c: conn}, nil
+}
+
+func (s *sht3xSensor) Read() (measurement, error) {
+	if _, err := s.i2c.WriteBytes([]byte{sht3xMeasureCmd >> 8, sht3xMeasureCmd & 0xff}); err != nil {
+		return measurement{}, fmt.Errorf("failed to start measurement: %w", err)
+	}
+	time.Sleep(sht3xMeasureDuration)
+
+	// The response is the temperature then the humidity, each as 2 bytes followed by a CRC byte
+	buf := make([]byte, 6)
+	if _, err := s.i2c.ReadBytes(buf); err != nil {
+		return measurement{}, fmt.Errorf("failed to read measurement from sensor: %w", err)
+	}
+	if crc := sht3xCRC(buf[0:2]); crc != buf[2] {
+		return measurement{}, fmt.Errorf("temperature CRC mismatch, expected %#x, got %#x", crc, buf[2])
+	}
+	if crc := sht3xCRC(buf[3:5]); crc != buf[5] {
+		return measurement{}, fmt.Errorf("humidity CRC mismatch, expected %#x, got %#x", crc, buf[5])
+	}
+
+	// Conversion formulas are from the datasheet
+	rawTemp := float32(uint16(buf[0])<<8 | uint16(buf[1]))
+	rawHumidity := float32(uint16(buf[3])<<8 | uint16(buf[4]))
+	temp := -45 + 175*rawTemp/65535
+	humidity := 100 * rawHumidity / 65535
+	return measurement{Temperature: temp, Humidity: &humidity}, nil
+}
+
+func (s *sht3xSensor) Close() error {
+	return s.i2c.Close()
+}
+
+// sht3xCRC computes the CRC-8 checksum used by the SHT3x, polynomial 0x31 with an initial value of 0xff.
+func sht3xCRC(data []byte) byte {
+	crc := byte(0xff)
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSHT3xCRC(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want byte
+	}{
+		// Example from the SHT3x datasheet
+		{"datasheet", []byte{0xbe, 0xef}, 0x92},
+		{"zero", []byte{0x00, 0x00}, 0x81},
+		{"empty", nil, 0xff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sht3xCRC(tt.data); got != tt.want {
+				t.Errorf("got %#02x, want %#02x", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// simulatedSensorConfig configures the readings produced by a simulated sensor.
+type simulatedSensorConfig struct {
+	// Baseline is the temperature in °C the sensor starts at.
+	Baseline float64 `yaml:"baseline"`
+	// Noise is the standard deviation of the random noise added to each reading.
+	Noise float64 `yaml:"noise"`
+	// Drift is how much the temperature changes per hour in °C.
+	Drift float64 `yaml:"drift"`
+	// FailureRate is the probability between 0 and 1 that a read fails.
+	FailureRate float64 `yaml:"failureRate"`
+	// Seed is used to seed the random number generator so runs can be reproduced.
+	Seed int64 `yaml:"seed"`
+}
+
+const (
+	// Baselines for humidity and pressure which aren't configurable since they aren't checked as closely.
+	simulatedHumidity = 40
+	simulatedPressure = 1013.25
+)
+
+var errSimulatedFailure = errors.New("simulated sensor failure")
+
+// simulatedSensor is a fake sensor that produces readings without any hardware.
+// It allows senseit to be run and tested anywhere.
+type simulatedSensor struct {
+	cfg   simulatedSensorConfig
+	rand  *rand.Rand
+	start time.Time
+}
+
+func newSimulatedSensor(cfg simulatedSensorConfig) *simulatedSensor {
+	return &simulatedSensor{
+		cfg:   cfg,
+		rand:  rand.New(rand.NewSource(cfg.Seed)),
+		start: time.Now(),
+	}
+}
+
+func (s *simulatedSensor) Read() (measurement, error) {
+	if s.rand.Float64() < s.cfg.FailureRate {
+		return measurement{}, errSimulatedFailure
+	}
+	hours := time.Since(s.start).Hours()
+	temp := float32(s.cfg.Baseline + s.cfg.Drift*hours + s.rand.NormFloat64()*s.cfg.Noise)
+	humidity := float32(simulatedHumidity + s.rand.NormFloat64()*s.cfg.Noise)
+	pressure := float32(simulatedPressure + s.rand.NormFloat64()*s.cfg.Noise)
+	return measurement{Temperature: temp, Humidity: &humidity, Pressure: &pressure}, nil
+}
+
+func (s *simulatedSensor) Close() error {
+	return nil
+}